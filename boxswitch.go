@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -23,26 +25,32 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-// Visual effect when switching moves with box on the floor
+// Visual effect when switching moves with box on the floor,
+// the boxes change size on their way when the level is
+// scaled by the camera
 type boxSwitcher struct {
-	floor, move          int
-	floorBoxX, floorBoxY float64
-	moveBoxX, moveBoxY   float64
-	frame, numFrames     int
-	dx, dy               float64
+	floor, move           int
+	floorBoxX, floorBoxY  float64
+	moveBoxX, moveBoxY    float64
+	floorScale, moveScale float64
+	frame, numFrames      int
+	dx, dy, dScale        float64
 }
 
-func (b *boxSwitcher) reset() {
-	b.numFrames = 0
+// Check if the effect is over
+func (b boxSwitcher) done() bool {
+	return b.frame >= b.numFrames
 }
 
 func (b *boxSwitcher) setUp(
-	charX, charY int, displayX, displayY float64,
+	floorX, floorY, scale float64,
 	numMoves int, switchMoveNum int,
 	bpm int, floorMove, seqMove int) {
 
-	b.floorBoxX = displayX + float64(charX*globalTileSize) + 6
-	b.floorBoxY = displayY + float64(charY*globalTileSize) + 16
+	b.floorBoxX = floorX + 6*scale
+	b.floorBoxY = floorY + 16*scale
+	b.floorScale = scale
+	b.moveScale = 1
 
 	b.moveBoxX = float64(globalScreenWidth-numMoves*globalButtonWidth)/2 +
 		float64(switchMoveNum*globalButtonWidth) +
@@ -57,6 +65,7 @@ func (b *boxSwitcher) setUp(
 
 	b.dx = distanceX / float64(b.numFrames)
 	b.dy = distanceY / float64(b.numFrames)
+	b.dScale = (1 - scale) / float64(b.numFrames)
 
 	b.floor = seqMove
 	b.move = floorMove
@@ -70,6 +79,8 @@ func (b *boxSwitcher) update() {
 		b.moveBoxY += b.dy
 		b.floorBoxX -= b.dx
 		b.floorBoxY -= b.dy
+		b.moveScale -= b.dScale
+		b.floorScale += b.dScale
 	}
 }
 
@@ -79,7 +90,8 @@ func (b boxSwitcher) draw(screen *ebiten.Image) {
 
 		// Box coming from floor
 		options := &ebiten.DrawImageOptions{}
-		options.GeoM.Translate(b.floorBoxX-16, b.floorBoxY-6)
+		options.GeoM.Scale(b.floorScale, b.floorScale)
+		options.GeoM.Translate(b.floorBoxX-16*b.floorScale, b.floorBoxY-6*b.floorScale)
 		imageNum := b.floor + levelUpBox + 1
 
 		screen.DrawImage(tilesImage.SubImage(
@@ -90,7 +102,8 @@ func (b boxSwitcher) draw(screen *ebiten.Image) {
 
 		// Box coming from move sequence
 		options = &ebiten.DrawImageOptions{}
-		options.GeoM.Translate(b.moveBoxX-16, b.moveBoxY-6)
+		options.GeoM.Scale(b.moveScale, b.moveScale)
+		options.GeoM.Translate(b.moveBoxX-16*b.moveScale, b.moveBoxY-6*b.moveScale)
 		imageNum = b.move + levelUpBox + 1
 
 		screen.DrawImage(tilesImage.SubImage(
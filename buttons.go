@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -18,19 +20,26 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
+	"fmt"
 	"image"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 // The set of buttons, can change at each level
 type buttonSet struct {
-	content        []button
-	onBeat         bool
-	firstLoop      bool
-	activePosition int
-	hasActive      bool
+	content         []button
+	onBeat          bool
+	firstLoop       bool
+	activePosition  int
+	hasActive       bool
+	pressed         bool
+	pressedPosition int
+	pressX, pressY  int
+	dragging        bool
+	dragX, dragY    int
 }
 
 // A button has a position and a size
@@ -42,6 +51,7 @@ type button struct {
 	positionInSequence  int
 	smallPosition       int
 	smallReset          bool
+	smallControls       bool
 }
 
 // Kinds of buttons
@@ -53,23 +63,31 @@ const (
 	buttonIncBPM
 	buttonDecBPM
 	buttonToggleSound
+	buttonPaletteMove
+	buttonAction
+	buttonHint
 )
 
-// Add small move buttons to a set
-func (bSet *buttonSet) addButtons(withReset bool) {
+// Number of pixels the cursor has to travel with the
+// mouse button pressed before a press becomes a drag
+const dragThreshold = 4
 
-	numButtons := 4
-	if withReset {
-		numButtons++
-	}
+// Add small move buttons to a set, one for each move
+// that can replace the one of the active slot (see
+// getMoveChoices)
+func (bSet *buttonSet) addButtons(sequence []int, withReset, withControls bool) {
+
+	position := bSet.content[bSet.activePosition].positionInSequence
+	numButtons := len(getMoveChoices(sequence[position], len(sequence), withReset, withControls))
 
 	buttonWidth := 28
 	buttonHeight := 38 + 3 // 3 is the shift when hovering
 	buttonSep := 4
 
 	x := bSet.content[bSet.activePosition].x - 6 + (globalButtonWidth-numButtons*(buttonWidth+buttonSep))/2
+	x = max(x, buttonSep+globalTileMargin)
+	x = min(x, globalScreenWidth-numButtons*(buttonWidth+buttonSep)-globalTileMargin)
 	y := bSet.content[bSet.activePosition].y - 6 - buttonHeight
-	position := bSet.content[bSet.activePosition].positionInSequence
 
 	for num := 0; num < numButtons; num++ {
 		bSet.content = append(bSet.content, button{
@@ -80,6 +98,7 @@ func (bSet *buttonSet) addButtons(withReset bool) {
 			positionInSequence: position,
 			smallPosition:      num,
 			smallReset:         withReset,
+			smallControls:      withControls,
 		})
 		x += buttonWidth + buttonSep
 	}
@@ -95,9 +114,10 @@ func (bSet *buttonSet) removeButtons() {
 
 }
 
-// Initialize the button set for a given level
-func (bSet *buttonSet) setupButtons(sequenceLen int) {
-	buttonSet := make([]button, sequenceLen+5, sequenceLen+10)
+// Initialize the button set for a given level, actionLen
+// is the length of the second track (0 if there is none)
+func (bSet *buttonSet) setupButtons(sequenceLen, actionLen int, withReset, withControls bool) {
+	buttonSet := make([]button, sequenceLen+5, sequenceLen+16)
 
 	// Play button
 	buttonSet[0] = button{
@@ -154,8 +174,48 @@ func (bSet *buttonSet) setupButtons(sequenceLen int) {
 		x += globalButtonWidth
 	}
 
+	// Palette of moves to drag into the sequence
+	paletteY := 95
+	for move := moveUp; move <= moveJump; move++ {
+		if (move == moveReset && !withReset) || (move > nothing && !withControls) {
+			continue
+		}
+		buttonSet = append(buttonSet, button{
+			drawX: 10, drawY: float64(paletteY),
+			x: 10, y: paletteY - globalTileMargin/4,
+			width: 28, height: 38 + 3,
+			kind:          buttonPaletteMove,
+			smallPosition: move,
+		})
+		paletteY += 42
+	}
+
+	// Hint, on the right
+	buttonSet = append(buttonSet, button{
+		drawX: globalScreenWidth - 80, drawY: 95,
+		x: globalScreenWidth - 80, y: 95,
+		width: 70, height: globalSmallButtonHeight,
+		kind: buttonHint,
+	})
+
+	// Actions of the second track, in a row above the sequence
+	x = (globalScreenWidth - actionLen*globalActionWidth) / 2
+	actionY := globalScreenHeight - globalButtonHeight - globalActionRowHeight + 6
+	for pos := 0; pos < actionLen; pos++ {
+		buttonSet = append(buttonSet, button{
+			drawX: float64(x + 4), drawY: float64(actionY),
+			x: x + 4, y: actionY,
+			width: globalActionWidth - 8, height: globalActionHeight,
+			kind:               buttonAction,
+			positionInSequence: pos,
+		})
+		x += globalActionWidth
+	}
+
 	bSet.content = buttonSet
 	bSet.hasActive = false
+	bSet.pressed = false
+	bSet.dragging = false
 }
 
 // Record if it is beat or half beat time
@@ -173,7 +233,7 @@ func (bSet *buttonSet) setFirstLoop() {
 }
 
 // Update the buttons
-func (bSet *buttonSet) update(cursorX, cursorY int, inSetUp bool, withReset bool) (click bool, clickKind int, positionInSequence int, smallPosition int) {
+func (bSet *buttonSet) update(cursorX, cursorY int, sequence []int, inSetUp bool, withReset, withControls bool) (click bool, clickKind int, positionInSequence int, smallPosition int, action drop) {
 
 	hoveredPos := -1
 
@@ -185,43 +245,227 @@ func (bSet *buttonSet) update(cursorX, cursorY int, inSetUp bool, withReset bool
 		}
 	}
 
+	// Drag and drop on the sequence (only during set up)
+	if bSet.pressed {
+		if !inSetUp {
+			bSet.pressed = false
+			bSet.dragging = false
+			return
+		}
+
+		bSet.dragX, bSet.dragY = cursorX, cursorY
+		if !bSet.dragging &&
+			(abs(cursorX-bSet.pressX) > dragThreshold || abs(cursorY-bSet.pressY) > dragThreshold) {
+			bSet.dragging = true
+			if bSet.hasActive {
+				bSet.hasActive = false
+				bSet.removeButtons()
+			}
+		}
+
+		if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+			bSet.pressed = false
+			if bSet.dragging {
+				bSet.dragging = false
+				action = bSet.getDrop(cursorX, hoveredPos)
+				return
+			}
+
+			// No drag, this was a simple click
+			pressedButton := bSet.content[bSet.pressedPosition]
+			click = true
+			clickKind = pressedButton.kind
+			positionInSequence = pressedButton.positionInSequence
+			smallPosition = pressedButton.smallPosition
+
+			if pressedButton.kind == buttonSequence {
+				bSet.toggleActive(bSet.pressedPosition, sequence, withReset, withControls)
+			} else if bSet.hasActive {
+				action = drop{
+					kind: dropSetMove,
+					move: pressedButton.smallPosition,
+					to:   bSet.content[bSet.activePosition].positionInSequence,
+				}
+				bSet.hasActive = false
+				bSet.removeButtons()
+			}
+		}
+
+		return
+	}
+
+	if inSetUp && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) &&
+		hoveredPos != -1 && bSet.content[hoveredPos].kind == buttonSequence {
+		action = drop{kind: dropClear, to: bSet.content[hoveredPos].positionInSequence}
+		if bSet.hasActive {
+			bSet.hasActive = false
+			bSet.removeButtons()
+		}
+		return
+	}
+
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && hoveredPos != -1 {
 
+		if inSetUp && (bSet.content[hoveredPos].kind == buttonSequence ||
+			bSet.content[hoveredPos].kind == buttonPaletteMove) {
+			bSet.pressed = true
+			bSet.pressedPosition = hoveredPos
+			bSet.pressX, bSet.pressY = cursorX, cursorY
+			bSet.dragX, bSet.dragY = cursorX, cursorY
+			return
+		}
+
 		click = true
 		clickKind = bSet.content[hoveredPos].kind
 		positionInSequence = bSet.content[hoveredPos].positionInSequence
 		smallPosition = bSet.content[hoveredPos].smallPosition
 
 		if inSetUp {
-			if bSet.content[hoveredPos].kind == buttonSequence {
-				if bSet.activePosition == hoveredPos && bSet.hasActive {
-					bSet.hasActive = false
-					bSet.removeButtons()
-				} else {
-					if bSet.hasActive {
-						bSet.removeButtons()
-					}
-					bSet.activePosition = hoveredPos
-					bSet.hasActive = true
-					bSet.addButtons(withReset)
-				}
-			} else if bSet.content[hoveredPos].kind == buttonSelectMove {
-				bSet.hasActive = false
-				bSet.removeButtons()
-			} else {
-				bSet.hasActive = false
-				bSet.removeButtons()
-			}
+			bSet.hasActive = false
+			bSet.removeButtons()
 		}
 	}
 
 	return
 }
 
+// Open the small move buttons above a sequence button,
+// or close them if they are already open for this button
+func (bSet *buttonSet) toggleActive(position int, sequence []int, withReset, withControls bool) {
+	if bSet.activePosition == position && bSet.hasActive {
+		bSet.hasActive = false
+		bSet.removeButtons()
+		return
+	}
+	if bSet.hasActive {
+		bSet.removeButtons()
+	}
+	bSet.activePosition = position
+	bSet.hasActive = true
+	bSet.addButtons(sequence, withReset, withControls)
+}
+
+// Get the edition of the sequence resulting from dropping
+// the dragged button: a move from the palette replaces the
+// move of a slot, a slot dropped on the center of another
+// one is swapped with it, and a slot dropped on the side of
+// another one is moved before or after it
+func (bSet buttonSet) getDrop(cursorX int, hoveredPos int) (action drop) {
+
+	if hoveredPos == -1 || hoveredPos >= len(bSet.content) ||
+		bSet.content[hoveredPos].kind != buttonSequence {
+		return
+	}
+
+	source := bSet.content[bSet.pressedPosition]
+	target := bSet.content[hoveredPos]
+
+	if source.kind == buttonPaletteMove {
+		return drop{kind: dropSetMove, move: source.smallPosition, to: target.positionInSequence}
+	}
+
+	if source.positionInSequence == target.positionInSequence {
+		return
+	}
+
+	relativeX := cursorX - target.x
+	switch {
+	case relativeX < target.width/4:
+		action = drop{kind: dropInsert, from: source.positionInSequence, to: target.positionInSequence}
+	case relativeX >= 3*target.width/4:
+		action = drop{kind: dropInsert, from: source.positionInSequence, to: target.positionInSequence + 1}
+	default:
+		action = drop{kind: dropSwap, from: source.positionInSequence, to: target.positionInSequence}
+	}
+
+	return
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Labels of the actions of the second track
+var actionLabels = [...]string{
+	actionNone:        "-",
+	actionSwitchWalls: "walls",
+	actionTurnArrows:  "turn",
+}
+
+// Draw the button of an action of the second track
+func drawAction(action int, b button, highlight bool, screen *ebiten.Image) {
+	drawLabelButton(actionLabels[action], b, highlight, screen)
+}
+
+// Draw a button as a box with a label
+func drawLabelButton(label string, b button, highlight bool, screen *ebiten.Image) {
+	if highlight {
+		vector.DrawFilledRect(screen, float32(b.drawX), float32(b.drawY),
+			float32(b.width), float32(b.height), crateColor, true)
+	}
+	vector.StrokeRect(screen, float32(b.drawX), float32(b.drawY),
+		float32(b.width), float32(b.height), 2, wallColor, true)
+	drawCenteredText(label,
+		b.drawX+float64(b.width)/2, b.drawY+float64(b.height)/2, smallFace, screen)
+}
+
+// Get the label of a control slot
+func getControlLabel(move int) string {
+	switch move {
+	case moveSkipIfBlocked:
+		return "skip"
+	case moveRepeat:
+		return "rep"
+	}
+	return fmt.Sprintf("go%d", move-moveJump+1)
+}
+
+// Draw a move as a box, (x, y) is the top left of the
+// image of the box in tiles.png. Control slots have no
+// image and are drawn as a crate with their label.
+func drawMoveBox(move int, x, y float64, screen *ebiten.Image) {
+	if move > nothing {
+		vector.DrawFilledRect(screen, float32(x)+globalTileMargin+2, float32(y)+globalTileMargin+2,
+			globalTileSize-4, globalTileSize-4, crateColor, true)
+		vector.StrokeRect(screen, float32(x)+globalTileMargin+2, float32(y)+globalTileMargin+2,
+			globalTileSize-4, globalTileSize-4, 2, wallColor, true)
+		drawCenteredText(getControlLabel(move),
+			x+globalTileMargin+globalTileSize/2, y+globalTileMargin+globalTileSize/2, smallFace, screen)
+		return
+	}
+
+	imageNum := move + levelUpBox + 1
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Translate(x, y)
+	screen.DrawImage(tilesImage.SubImage(
+		image.Rect(imageNum*(globalTileSize+2*globalTileMargin), 0,
+			(imageNum+1)*(globalTileSize+2*globalTileMargin),
+			globalTileSize+2*globalTileMargin)).(*ebiten.Image),
+		options)
+}
+
 // Draw the buttons
-func (buttonSet buttonSet) draw(sequence []int, currentPosition int, hideMove bool, inPlay bool, musicOn bool, screen *ebiten.Image) {
+func (buttonSet buttonSet) draw(sequence []int, currentPosition int, actions []int, currentAction int,
+	hideMove bool, inPlay bool, musicOn bool, screen *ebiten.Image) {
 
 	for buttonNum, button := range buttonSet.content {
+		if button.kind == buttonAction {
+			highlight := (button.hover && !inPlay) ||
+				(inPlay && buttonSet.onBeat && !buttonSet.firstLoop && button.positionInSequence == currentAction)
+			drawAction(actions[button.positionInSequence], button, highlight, screen)
+			continue
+		}
+
+		if button.kind == buttonHint {
+			if !inPlay {
+				drawLabelButton("hint", button, button.hover, screen)
+			}
+			continue
+		}
+
 		options := &ebiten.DrawImageOptions{}
 		options.GeoM.Translate(button.drawX, button.drawY)
 
@@ -258,26 +502,21 @@ func (buttonSet buttonSet) draw(sequence []int, currentPosition int, hideMove bo
 				directionNum += 2 * nothing
 			}
 		case buttonSelectMove:
-			imageNum = button.smallPosition
-			if imageNum >= sequence[button.positionInSequence] {
-				imageNum++
-			}
-			if !button.smallReset && imageNum >= moveReset {
-				imageNum++
+			imageNum = getMoveFromChoice(button.smallPosition, sequence[button.positionInSequence],
+				len(sequence), button.smallReset, button.smallControls)
+		case buttonPaletteMove:
+			if inPlay {
+				continue
 			}
-			imageNum += levelUpBox + 1
+			imageNum = button.smallPosition
 		}
 
-		if button.kind == buttonSelectMove {
-			options.GeoM.Translate(-16, -6)
+		if button.kind == buttonSelectMove || button.kind == buttonPaletteMove {
+			y := button.drawY - 6
 			if button.hover {
-				options.GeoM.Translate(0, 3)
+				y += 3
 			}
-			screen.DrawImage(tilesImage.SubImage(
-				image.Rect(imageNum*(globalTileSize+2*globalTileMargin), 0,
-					(imageNum+1)*(globalTileSize+2*globalTileMargin),
-					globalTileSize+2*globalTileMargin)).(*ebiten.Image),
-				options)
+			drawMoveBox(imageNum, button.drawX-16, y, screen)
 			continue
 		}
 
@@ -309,6 +548,11 @@ func (buttonSet buttonSet) draw(sequence []int, currentPosition int, hideMove bo
 			options)
 
 		if button.kind == buttonSequence &&
+			sequence[button.positionInSequence] > nothing &&
+			!hideMove {
+			drawCenteredText(getControlLabel(sequence[button.positionInSequence]),
+				button.drawX+globalButtonWidth/2, button.drawY+globalButtonHeight/2+8, ocpFace, screen)
+		} else if button.kind == buttonSequence &&
 			sequence[button.positionInSequence] != nothing &&
 			!hideMove {
 			screen.DrawImage(buttonsImage.SubImage(
@@ -319,6 +563,16 @@ func (buttonSet buttonSet) draw(sequence []int, currentPosition int, hideMove bo
 		}
 	}
 
+	// Move being dragged
+	if buttonSet.dragging {
+		dragged := buttonSet.content[buttonSet.pressedPosition]
+		move := dragged.smallPosition
+		if dragged.kind == buttonSequence {
+			move = sequence[dragged.positionInSequence]
+		}
+		drawMoveBox(move, float64(buttonSet.dragX-30), float64(buttonSet.dragY-30), screen)
+	}
+
 	/*
 		startY := float64(globalScreenHeight - globalButtonHeight)
 
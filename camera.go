@@ -0,0 +1,165 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Levels are drawn on a world image that is shown in the
+// view (the part of the screen above the buttons) through
+// a camera. Levels that fit in the view are shown as they
+// are, larger ones are scaled down to fit, and the camera
+// follows the CUB in levels too large to be scaled down
+// enough. During set up the player can move the camera
+// with the arrow keys or by dragging with the right mouse
+// button.
+type camera struct {
+	world            *ebiten.Image
+	levelNum         int
+	x, y             float64
+	scale            float64
+	follow           bool
+	dragging         bool
+	dragX, dragY     int
+	viewWidth        float64
+	viewHeight       float64
+	worldW, worldH   float64
+	targetX, targetY float64
+}
+
+// Smallest scale used to fit a level in the view
+const cameraMinScale = 0.5
+
+// Speed of the camera when moved with the keyboard,
+// in screen pixels per frame
+const cameraPanSpeed = 8
+
+// Set up the camera for the world of the current level
+func (cam *camera) setUp(c character, levelNum int) {
+	cam.levelNum = levelNum
+	cam.worldW, cam.worldH = float64(c.worldWidth), float64(c.worldHeight)
+	cam.viewWidth, cam.viewHeight = globalScreenWidth, float64(c.viewHeight)
+	if cam.world == nil || cam.world.Bounds().Dx() != c.worldWidth || cam.world.Bounds().Dy() != c.worldHeight {
+		cam.world = ebiten.NewImage(c.worldWidth, c.worldHeight)
+	}
+
+	cam.scale = min(1, cam.viewWidth/cam.worldW, cam.viewHeight/cam.worldH)
+	cam.follow = cam.scale < cameraMinScale
+	if cam.follow {
+		cam.scale = cameraMinScale
+		cam.lookAt(c)
+		cam.x, cam.y = cam.targetX, cam.targetY
+	} else {
+		cam.x = (cam.worldW - cam.viewWidth/cam.scale) / 2
+		cam.y = (cam.worldH - cam.viewHeight/cam.scale) / 2
+	}
+}
+
+// Set the target of the camera on the first CUB
+func (cam *camera) lookAt(c character) {
+	if len(c.actors) == 0 {
+		return
+	}
+	cam.targetX = c.displayX + float64(c.actors[0].x*globalTileSize+globalTileSize/2) - cam.viewWidth/cam.scale/2
+	cam.targetY = c.displayY + float64(c.actors[0].y*globalTileSize+globalTileSize/2) - cam.viewHeight/cam.scale/2
+	cam.targetX, cam.targetY = cam.clamp(cam.targetX, cam.targetY)
+}
+
+// Keep a camera position inside the world
+func (cam camera) clamp(x, y float64) (float64, float64) {
+	x = max(0, min(x, cam.worldW-cam.viewWidth/cam.scale))
+	y = max(0, min(y, cam.worldH-cam.viewHeight/cam.scale))
+	return x, y
+}
+
+// Set up the camera again if the level changed
+func (cam *camera) check(c character, levelNum int) {
+	if cam.world == nil || levelNum != cam.levelNum ||
+		float64(c.worldWidth) != cam.worldW || float64(c.worldHeight) != cam.worldH ||
+		float64(c.viewHeight) != cam.viewHeight {
+		cam.setUp(c, levelNum)
+	}
+}
+
+// Move the camera: follow the CUB while a sequence is
+// played, and let the player move it during set up
+func (cam *camera) update(c character, levelNum int, inSetUp bool, cursorX, cursorY int) {
+	cam.check(c, levelNum)
+
+	if !cam.follow {
+		return
+	}
+
+	if !inSetUp {
+		cam.dragging = false
+		cam.lookAt(c)
+		cam.x += (cam.targetX - cam.x) / 8
+		cam.y += (cam.targetY - cam.y) / 8
+		return
+	}
+
+	step := cameraPanSpeed / cam.scale
+	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) {
+		cam.x -= step
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) {
+		cam.x += step
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowUp) {
+		cam.y -= step
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) {
+		cam.y += step
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) && float64(cursorY) < cam.viewHeight {
+		cam.dragging = true
+		cam.dragX, cam.dragY = cursorX, cursorY
+	}
+	if cam.dragging {
+		cam.x -= float64(cursorX-cam.dragX) / cam.scale
+		cam.y -= float64(cursorY-cam.dragY) / cam.scale
+		cam.dragX, cam.dragY = cursorX, cursorY
+		if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+			cam.dragging = false
+		}
+	}
+
+	cam.x, cam.y = cam.clamp(cam.x, cam.y)
+}
+
+// Get the position on screen of a position in the world
+func (cam camera) toScreen(x, y float64) (float64, float64) {
+	return (x - cam.x) * cam.scale, (y - cam.y) * cam.scale
+}
+
+// Draw the world in the view
+func (cam camera) draw(screen *ebiten.Image) {
+	view := screen.SubImage(image.Rect(0, 0, int(cam.viewWidth), int(cam.viewHeight))).(*ebiten.Image)
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Translate(-cam.x, -cam.y)
+	options.GeoM.Scale(cam.scale, cam.scale)
+	options.Filter = ebiten.FilterLinear
+	view.DrawImage(cam.world, options)
+}
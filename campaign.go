@@ -0,0 +1,71 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+// A rule of a game mode giving the speed of the music for
+// a level, from the speed of the previous level, the speed
+// chosen by the player, and the number of frames taken to
+// solve the previous level
+type bpmRule func(levelNum, bpm, playerBPM, frames int) int
+
+// Speed of the music in the levels of the campaign that
+// teach something new (the first level of each step)
+var campaignTeachingBPMs = [len(levelSteps)]int{50, 30, 40}
+
+// Tell if a level of the campaign teaches something new
+func isTeachingLevel(levelNum int) bool {
+	_, teaching := campaignTeachingBPM(levelNum)
+	return teaching
+}
+
+// Get the speed of the music of a level of the campaign
+// that teaches something new
+func campaignTeachingBPM(levelNum int) (bpm int, teaching bool) {
+	for step, stepStart := range levelSteps {
+		if levelNum == stepStart {
+			return campaignTeachingBPMs[step], true
+		}
+	}
+	return 0, false
+}
+
+// Rule of the campaign: the music slows down in the levels
+// that teach something new, and goes back to the speed
+// chosen by the player after them
+func campaignBPM(levelNum, bpm, playerBPM, frames int) int {
+	if teachingBPM, teaching := campaignTeachingBPM(levelNum); teaching {
+		return teachingBPM
+	}
+	return playerBPM
+}
+
+// Get the speed of the music for the level after a given
+// one and the speed chosen by the player, which is the
+// speed of the given level unless it taught something new.
+// Once the campaign is over the music goes back to
+// defaultBPM, whatever the rule of the game mode.
+func nextLevelBPM(levelNum, bpm, playerBPM, defaultBPM int, rule bpmRule, frames int) (nextBPM, nextPlayerBPM int) {
+	nextPlayerBPM = playerBPM
+	if !isTeachingLevel(levelNum) {
+		nextPlayerBPM = bpm
+	}
+	if levelNum+1 >= len(levelSet) {
+		return defaultBPM, nextPlayerBPM
+	}
+	return rule(levelNum+1, bpm, nextPlayerBPM, frames), nextPlayerBPM
+}
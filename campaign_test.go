@@ -0,0 +1,64 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import "testing"
+
+// The music of the campaign slows down in the levels that
+// teach something new and goes back to the speed chosen by
+// the player after them.
+func TestCampaignBPM(t *testing.T) {
+	defer func(saved [len(levelSteps)]int) { levelSteps = saved }(levelSteps)
+	levelSteps = [len(levelSteps)]int{2, 5, 9}
+	for levelNum, want := range map[int]int{1: 90, 2: 50, 3: 90, 5: 30, 9: 40} {
+		if bpm := campaignBPM(levelNum, 70, 90, 0); bpm != want {
+			t.Fatalf("got %d BPM at level %d, want %d", bpm, levelNum, want)
+		}
+	}
+}
+
+// Going to the next level follows the rule of the game
+// mode and remembers the speed chosen by the player, and
+// the music goes back to the default speed once the last
+// level is solved.
+func TestNextLevelBPM(t *testing.T) {
+	defer func(saved [len(levelSteps)]int) { levelSteps = saved }(levelSteps)
+	defer func(saved []level) { levelSet = saved }(levelSet)
+	levelSteps = [len(levelSteps)]int{2, 5, 9}
+	levelSet = make([]level, 12)
+
+	for _, test := range []struct {
+		levelNum, bpm, playerBPM int
+		rule                     bpmRule
+		frames                   int
+		wantBPM, wantPlayerBPM   int
+	}{
+		{1, 70, 90, campaignBPM, 0, 50, 70},
+		{2, 50, 70, campaignBPM, 0, 70, 70},
+		{3, 100, 70, timeAttackBPM, 0, 100 + timeAttackFastStep, 100},
+		{10, 70, 70, campaignBPM, 0, 70, 70},
+		{11, 120, 70, campaignBPM, 0, 60, 120},
+		{11, 120, 70, timeAttackBPM, 0, 60, 120},
+	} {
+		bpm, playerBPM := nextLevelBPM(test.levelNum, test.bpm, test.playerBPM, 60, test.rule, test.frames)
+		if bpm != test.wantBPM || playerBPM != test.wantPlayerBPM {
+			t.Errorf("after level %d at %d BPM: got %d BPM (player %d), want %d (player %d)",
+				test.levelNum, test.bpm, bpm, playerBPM, test.wantBPM, test.wantPlayerBPM)
+		}
+	}
+}
@@ -17,29 +17,68 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 package main
 
-import (
-	"image"
-
-	"github.com/hajimehoshi/ebiten/v2"
-)
-
-// The character position, sequence of moves (that will
-// be played in loop), and the current level (an array
-// of things that can be floor, walls, etc).
+// The characters (CUBs) positions, sequence of moves
+// (that will be played in loop by all the characters),
+// sequence of actions on the level (played in loop too,
+// in levels that have a second track), and the current
+// level (an array of things that can be floor, walls,
+// etc). Keys are shared by all the characters.
 type character struct {
-	x, y                   int
+	actors                 []actor
 	moveSequence           []int
 	originalMoveSequence   []int
 	nextMovePosition       int
 	currentMovePosition    int
+	actionSequence         []int
+	originalActionSequence []int
+	nextActionPosition     int
+	currentActionPosition  int
 	HideMove               bool
 	levelArea              [][]int
-	levelGoalX, levelGoalY int
+	objects                [][]int
+	goals                  []position
 	displayX, displayY     float64
+	worldWidth             int
+	worldHeight            int
+	viewHeight             int
 	onBeat                 bool
+	beats                  int
+	loops                  int
+	swaps                  int
+	blocked                int
+	keys                   [2]int
+	wallsToggled           bool
+	wallsSwitched          bool
+	hazards                []hazard
+	caught                 bool
+	chips                  int
+	lastMove               int
+	lastBlocked            bool
+}
+
+// One of the characters of a level. Actors are
+// always handled in the order of their starting
+// positions in the level (top to bottom, left to
+// right).
+type actor struct {
+	x, y           int
+	onToggleSwitch bool
+}
+
+// A hazard patrolling the level with its own loop
+// of moves, one move on each beat. Touching a hazard
+// makes the characters restart.
+type hazard struct {
+	x, y             int
+	moves            []int
+	nextMovePosition int
 }
 
-// The possible moves of the character.
+// The possible moves of the character. After nothing
+// come the control slots: skip the next slot if the last
+// move was blocked, repeat the last move, and jump to a
+// given slot (moveJump jumps to the first slot, moveJump+1
+// to the second one, and so on).
 const (
 	moveUp int = iota
 	moveRight
@@ -47,25 +86,113 @@ const (
 	moveLeft
 	moveReset
 	nothing
+	moveSkipIfBlocked
+	moveRepeat
+	moveJump
+	moveLastJump = moveJump + 7
+)
+
+// The possible actions of the second track: switch
+// the switch walls, or turn the auto move tiles
+// clockwise.
+const (
+	actionNone int = iota
+	actionSwitchWalls
+	actionTurnArrows
+	numActions
+)
+
+// Something that happened to an actor on a half
+// beat and that is shown with a visual effect.
+type halfBeatEvent struct {
+	kind               int
+	fromX, fromY       int
+	toX, toY           int
+	tile               int
+	floorMove, seqMove int
+}
+
+// The kinds of half beat events.
+const (
+	eventBoxSwitch int = iota
+	eventTeleport
+)
+
+// Kinds of edition of the sequence that can result
+// from a drag and drop or from a right click
+const (
+	dropNone int = iota
+	dropSetMove
+	dropSwap
+	dropInsert
+	dropClear
 )
 
+// A drop tells how the sequence of moves should be
+// edited: move is the move to set (for dropSetMove),
+// from and to are positions in the sequence
+type drop struct {
+	kind     int
+	move     int
+	from, to int
+}
+
 // Store and restore move sequence
 func (c *character) storeMoves() {
 	copy(c.originalMoveSequence, c.moveSequence)
+	copy(c.originalActionSequence, c.actionSequence)
 }
 
 func (c *character) restoreMoves() {
 	copy(c.moveSequence, c.originalMoveSequence)
+	copy(c.actionSequence, c.originalActionSequence)
 	c.HideMove = false
 }
 
+// Count the moves (other than nothing) in the
+// sequence the character started with
+func (c character) countMoves() (movesUsed int) {
+	for _, move := range c.originalMoveSequence {
+		if move != nothing {
+			movesUsed++
+		}
+	}
+	return
+}
+
+// Edit the sequence of moves as asked by a drop
+// (see buttonSet.getDrop).
+func (c *character) editSequence(action drop) {
+	switch action.kind {
+	case dropSetMove:
+		c.moveSequence[action.to] = action.move
+	case dropClear:
+		c.moveSequence[action.to] = nothing
+	case dropSwap:
+		c.moveSequence[action.from], c.moveSequence[action.to] =
+			c.moveSequence[action.to], c.moveSequence[action.from]
+	case dropInsert:
+		move := c.moveSequence[action.from]
+		to := action.to
+		if to > action.from {
+			to--
+			copy(c.moveSequence[action.from:to], c.moveSequence[action.from+1:to+1])
+		} else {
+			copy(c.moveSequence[to+1:action.from+1], c.moveSequence[to:action.from])
+		}
+		c.moveSequence[to] = move
+	}
+}
+
 // Reset a given level by emptying the sequence
-// of moves, moving the character to the start,
+// of moves, moving the characters to the start,
 // copying the area (in case consumables were
 // used).
 func (c *character) reset(level level, resetSequence bool) {
-	c.x = level.startX
-	c.y = level.startY
+	c.actors = make([]actor, len(level.starts))
+	for a, start := range level.starts {
+		c.actors[a] = actor{x: start.x, y: start.y}
+	}
 	if resetSequence {
 		c.moveSequence = make([]int, level.sequenceLen)
 		c.originalMoveSequence = make([]int, level.sequenceLen)
@@ -73,55 +200,180 @@ func (c *character) reset(level level, resetSequence bool) {
 			c.moveSequence[pos] = nothing
 			c.originalMoveSequence[pos] = nothing
 		}
+		c.actionSequence = make([]int, level.actionLen)
+		c.originalActionSequence = make([]int, level.actionLen)
 	}
 	c.nextMovePosition = 0
 	c.currentMovePosition = 0
+	c.nextActionPosition = 0
+	c.currentActionPosition = 0
+	c.lastMove = nothing
+	c.lastBlocked = false
+	c.beats = 0
+	c.loops = 0
+	c.swaps = 0
+	c.blocked = 0
+	c.keys = [2]int{}
+	c.chips = 0
+	c.wallsToggled = false
+	c.wallsSwitched = false
+	c.hazards = make([]hazard, len(level.hazards))
+	copy(c.hazards, level.hazards)
+	c.caught = false
 	c.levelArea = make([][]int, len(level.area))
 	for linePos, line := range level.area {
 		c.levelArea[linePos] = make([]int, len(line))
 		copy(c.levelArea[linePos], line)
 	}
-	c.levelGoalX = level.goalX
-	c.levelGoalY = level.goalY
-	c.displayY = float64(globalScreenHeight-globalButtonHeight-len(level.area)*globalTileSize) / 2
+	c.objects = make([][]int, len(level.objects))
+	for linePos, line := range level.objects {
+		c.objects[linePos] = make([]int, len(line))
+		copy(c.objects[linePos], line)
+	}
+	c.goals = level.goals
+	c.viewHeight = globalScreenHeight - globalButtonHeight
+	if level.actionLen > 0 {
+		c.viewHeight -= globalActionRowHeight
+	}
+	areaWidth, areaHeight := 0, len(level.area)*globalTileSize
 	if len(level.area) > 0 {
-		c.displayX = float64(globalScreenWidth-len(level.area[0])*globalTileSize) / 2
+		areaWidth = len(level.area[0]) * globalTileSize
 	}
+	c.worldWidth = max(globalScreenWidth, areaWidth+2*globalWorldMargin)
+	c.worldHeight = max(c.viewHeight, areaHeight+2*globalWorldMargin)
+	c.displayX = float64(c.worldWidth-areaWidth) / 2
+	c.displayY = float64(c.worldHeight-areaHeight) / 2
 	c.HideMove = false
 }
 
-// The character performs one step of its
+// The characters perform one step of their
 // sequence of moves at each beat. If the
 // step is not "do nothing" then a sound is
-// played on the beat.
+// played on the beat. The move counts as
+// blocked as soon as one character cannot do it.
+// Control slots take a beat too, during which
+// the characters stay in place (unless they
+// repeat the last move).
 func (c *character) updateOnBeat() (playSound bool, soundID int) {
 	c.HideMove = false
-	if c.applyMove(c.moveSequence[c.nextMovePosition]) {
-		playSound, soundID = getMoveSoundId(c.moveSequence[c.nextMovePosition])
+	c.beats++
+	if c.nextMovePosition == 0 {
+		c.loops++
+	}
+	slot := c.moveSequence[c.nextMovePosition]
+	move := slot
+	if move == moveRepeat {
+		move = c.lastMove
+	}
+	moves := make([]int, len(c.actors))
+	from := make([]position, len(c.actors))
+	for a := range moves {
+		moves[a] = move
+		from[a] = position{c.actors[a].x, c.actors[a].y}
+	}
+	success := true
+	for _, moved := range c.moveActors(moves) {
+		success = success && moved
+	}
+	if len(c.actionSequence) > 0 {
+		c.currentActionPosition = c.nextActionPosition
+		c.applyAction(c.actionSequence[c.currentActionPosition])
+		c.nextActionPosition = (c.nextActionPosition + 1) % len(c.actionSequence)
+	}
+	c.moveHazards(from)
+	if success {
+		playSound, soundID = getMoveSoundId(move)
 	} else {
 		playSound = true
 		soundID = soundBlip
+		c.blocked++
 	}
 	c.currentMovePosition = c.nextMovePosition
-	if c.moveSequence[c.nextMovePosition] == moveReset {
+	switch {
+	case move == moveReset:
 		c.nextMovePosition = 0
-	} else {
+	case move >= moveJump:
+		c.nextMovePosition = (move - moveJump) % len(c.moveSequence)
+	case slot == moveSkipIfBlocked && c.lastBlocked:
+		c.nextMovePosition = (c.nextMovePosition + 2) % len(c.moveSequence)
+	default:
 		c.nextMovePosition = (c.nextMovePosition + 1) % len(c.moveSequence)
 	}
+	if move <= nothing {
+		c.lastMove = move
+		c.lastBlocked = !success
+	}
 	return
 }
 
+// Move each hazard one step in its loop. Hazards are
+// blocked by everything that blocks a character and
+// by doors and crates. The characters are caught if
+// a hazard reaches them or crosses their way (from
+// gives the positions of the characters before their
+// move).
+func (c *character) moveHazards(from []position) {
+	for h := range c.hazards {
+		hzd := &c.hazards[h]
+		if len(hzd.moves) == 0 {
+			continue
+		}
+		move := hzd.moves[hzd.nextMovePosition]
+		xTo, yTo := getDestination(hzd.x, hzd.y, move)
+		if c.isAccessible(xTo, yTo, move) && c.objects[yTo][xTo] == objectNone &&
+			c.levelArea[yTo][xTo] != levelDoorA && c.levelArea[yTo][xTo] != levelDoorB {
+			for a, act := range c.actors {
+				if act.x == hzd.x && act.y == hzd.y && from[a].x == xTo && from[a].y == yTo {
+					c.caught = true
+				}
+			}
+			hzd.x, hzd.y = xTo, yTo
+		}
+		if move == moveReset {
+			hzd.nextMovePosition = 0
+		} else {
+			hzd.nextMovePosition = (hzd.nextMovePosition + 1) % len(hzd.moves)
+		}
+	}
+	c.checkHazards()
+}
+
+// Check if a hazard stands on a character.
+func (c *character) checkHazards() {
+	for _, hzd := range c.hazards {
+		if c.actorAt(hzd.x, hzd.y) >= 0 {
+			c.caught = true
+		}
+	}
+}
+
+// Apply an action of the second track. Switching the
+// walls works like a toggle switch, so the walls move
+// on the next half beat.
+func (c *character) applyAction(action int) {
+	switch action {
+	case actionSwitchWalls:
+		c.wallsToggled = !c.wallsToggled
+	case actionTurnArrows:
+		for y := range c.levelArea {
+			for x := range c.levelArea[y] {
+				switch c.levelArea[y][x] {
+				case levelUp, levelRight, levelDown, levelLeft:
+					c.levelArea[y][x] = levelUp + (c.levelArea[y][x]-levelUp+1)%4
+				}
+			}
+		}
+	}
+}
+
 func (c *character) setBeat() {
 	c.onBeat = true
 }
 
-// Get the effect of a given move on the character
-// depending of the area and the character current
-// position.
-func (c *character) applyMove(move int) (success bool) {
-
-	xTo := c.x
-	yTo := c.y
+// Get the position reached from a given one
+// with a given move.
+func getDestination(x, y int, move int) (xTo, yTo int) {
+	xTo, yTo = x, y
 	switch move {
 	case moveUp:
 		yTo--
@@ -132,27 +384,204 @@ func (c *character) applyMove(move int) (success bool) {
 	case moveLeft:
 		xTo--
 	}
+	return
+}
+
+// Get the actor at a given position, -1 if
+// there is none.
+func (c character) actorAt(x, y int) int {
+	for a, act := range c.actors {
+		if act.x == x && act.y == y {
+			return a
+		}
+	}
+	return -1
+}
+
+// Move all the actors at the same time, each
+// one with its own move. An actor cannot enter
+// a position where another actor stays, but it
+// can follow an actor that moves away. When two
+// actors want to reach the same position the
+// first one gets it and the other one is blocked,
+// and two actors cannot swap their positions.
+func (c *character) moveActors(moves []int) (moved []bool) {
+	moved = make([]bool, len(c.actors))
+	done := make([]bool, len(c.actors))
+	for progress := true; progress; {
+		progress = false
+		for a := range c.actors {
+			if done[a] {
+				continue
+			}
+			xTo, yTo := getDestination(c.actors[a].x, c.actors[a].y, moves[a])
+			if other := c.actorAt(xTo, yTo); other >= 0 && other != a {
+				continue
+			}
+			moved[a] = c.applyMove(a, moves[a])
+			done[a], progress = true, true
+		}
+	}
+	return
+}
+
+// Get the effect of a given move on an actor
+// depending of the area and the actor current
+// position.
+func (c *character) applyMove(a int, move int) (success bool) {
+
+	xTo, yTo := getDestination(c.actors[a].x, c.actors[a].y, move)
 
-	success = c.isAccessible(xTo, yTo)
+	success = c.isAccessible(xTo, yTo, move) &&
+		(c.objects[yTo][xTo] != objectCrate || c.pushCrate(xTo, yTo, move))
 
 	if success {
-		c.x = xTo
-		c.y = yTo
+		c.moveTo(a, xTo, yTo)
 	}
 
 	return
 }
 
+// Move an actor to a given position, applying
+// the effects of leaving its current position and
+// of reaching the new one.
+func (c *character) moveTo(a int, x, y int) {
+	act := &c.actors[a]
+	if x == act.x && y == act.y {
+		return
+	}
+	if c.levelArea[act.y][act.x] == levelCrumble {
+		c.levelArea[act.y][act.x] = levelEmpty
+	}
+	act.x = x
+	act.y = y
+	c.openDoor(a)
+}
+
 // Check if a given position in the area is
-// suitable for the character to stay on.
-func (c character) isAccessible(x, y int) bool {
-	return x >= 0 && y >= 0 &&
-		y < len(c.levelArea) && x < len(c.levelArea[y]) &&
-		c.levelArea[y][x] != levelWall &&
-		c.levelArea[y][x] != levelCeiling
+// suitable for a character to stay on when
+// coming with a given move (nothing when not
+// coming from a neighbour position). Doors are
+// accessible with a matching key, and one way
+// floors only when moving in their direction.
+func (c character) isAccessible(x, y int, move int) bool {
+	if x < 0 || y < 0 || y >= len(c.levelArea) || x >= len(c.levelArea[y]) {
+		return false
+	}
+	switch c.levelArea[y][x] {
+	case levelWall, levelCeiling, levelSwitchWallUp, levelEmpty:
+		return false
+	case levelDoorA, levelDoorB:
+		return c.keys[c.levelArea[y][x]-levelDoorA] > 0
+	case levelOneWayUp, levelOneWayRight, levelOneWayDown, levelOneWayLeft:
+		return move > moveLeft || move == c.levelArea[y][x]-levelOneWayUp
+	}
+	return true
+}
+
+// Push the crate at a given position following a move
+// of a character. The crate is blocked by walls, doors,
+// characters and other crates. A crate pushed in a pit
+// fills it, and a crate pushed on a toggle switch
+// switches the walls.
+func (c *character) pushCrate(x, y int, move int) (success bool) {
+	if move > moveLeft {
+		return false
+	}
+	xTo, yTo := getDestination(x, y, move)
+
+	if xTo < 0 || yTo < 0 || yTo >= len(c.levelArea) || xTo >= len(c.levelArea[yTo]) ||
+		c.objects[yTo][xTo] != objectNone || c.actorAt(xTo, yTo) >= 0 {
+		return false
+	}
+
+	switch c.levelArea[yTo][xTo] {
+	case levelWall, levelCeiling, levelSwitchWallUp, levelDoorA, levelDoorB:
+		return false
+	case levelOneWayUp, levelOneWayRight, levelOneWayDown, levelOneWayLeft:
+		if move != c.levelArea[yTo][xTo]-levelOneWayUp {
+			return false
+		}
+	}
+
+	c.objects[y][x] = objectNone
+	switch c.levelArea[yTo][xTo] {
+	case levelEmpty:
+		c.levelArea[yTo][xTo] = levelFloor
+	case levelSwitchToggle:
+		c.wallsToggled = !c.wallsToggled
+		c.objects[yTo][xTo] = objectCrate
+	default:
+		c.objects[yTo][xTo] = objectCrate
+	}
+
+	return true
+}
+
+// Open the door an actor stands on (if any),
+// using one of the keys.
+func (c *character) openDoor(a int) {
+	x, y := c.actors[a].x, c.actors[a].y
+	switch c.levelArea[y][x] {
+	case levelDoorA, levelDoorB:
+		c.keys[c.levelArea[y][x]-levelDoorA]--
+		c.levelArea[y][x] = levelFloor
+	}
+}
+
+// Raise or lower the switch walls depending on the
+// switches: the walls are switched when a character
+// or a crate stands on a hold switch, and each time a
+// character steps on a toggle switch (crates switch the
+// walls when they are pushed, see pushCrate). Walls are
+// not raised under a character or a crate, so switching
+// waits for them to leave.
+func (c *character) updateSwitches() (switched bool) {
+	holdPressed := false
+	blocked := false
+	for a := range c.actors {
+		act := &c.actors[a]
+		tile := c.levelArea[act.y][act.x]
+		onToggleSwitch := tile == levelSwitchToggle
+		if onToggleSwitch && !act.onToggleSwitch {
+			c.wallsToggled = !c.wallsToggled
+		}
+		act.onToggleSwitch = onToggleSwitch
+		holdPressed = holdPressed || tile == levelSwitchHold
+		blocked = blocked || tile == levelSwitchWallDown
+	}
+
+	for y := range c.objects {
+		for x := range c.objects[y] {
+			if c.objects[y][x] == objectCrate {
+				holdPressed = holdPressed || c.levelArea[y][x] == levelSwitchHold
+				blocked = blocked || c.levelArea[y][x] == levelSwitchWallDown
+			}
+		}
+	}
+
+	shouldSwitch := c.wallsToggled != holdPressed
+	if shouldSwitch == c.wallsSwitched || blocked {
+		return false
+	}
+
+	for y := range c.levelArea {
+		for x := range c.levelArea[y] {
+			switch c.levelArea[y][x] {
+			case levelSwitchWallUp:
+				c.levelArea[y][x] = levelSwitchWallDown
+			case levelSwitchWallDown:
+				c.levelArea[y][x] = levelSwitchWallUp
+			}
+		}
+	}
+	c.wallsSwitched = shouldSwitch
+
+	return true
 }
 
 // Given a move, get the corresponding sound ID.
+// Control slots sound like reset.
 func getMoveSoundId(move int) (playSound bool, soundID int) {
 	if move == nothing {
 		return false, 0
@@ -167,7 +596,7 @@ func getMoveSoundId(move int) (playSound bool, soundID int) {
 		soundID = soundC4
 	case moveLeft:
 		soundID = soundG4
-	case moveReset:
+	default:
 		soundID = soundE4
 	}
 
@@ -176,65 +605,127 @@ func getMoveSoundId(move int) (playSound bool, soundID int) {
 
 // On each half beat consumables are consumed
 // and their effects are applied. This produces
-// a sound on the half beat.
-func (c *character) updateOnHalfBeat() (playSound bool, soundID int, switchBoxes bool) {
-
-	effect := c.levelArea[c.y][c.x]
+// a sound on the half beat. Characters on move
+// tiles all move at the same time, then the other
+// effects are applied character by character in
+// their order: when several characters stand on
+// boxes, each one swaps its box with the current
+// move in turn, so the move left in the sequence
+// is the box of the last one.
+func (c *character) updateOnHalfBeat() (playSound bool, soundID int, events []halfBeatEvent) {
+
+	effects := make([]int, len(c.actors))
+	moves := make([]int, len(c.actors))
+	autoMove := false
+	for a, act := range c.actors {
+		effects[a] = c.levelArea[act.y][act.x]
+		moves[a] = nothing
+		switch effects[a] {
+		case levelUp, levelLeft, levelDown, levelRight:
+			moves[a] = effects[a] - levelUp
+			autoMove = true
+		}
+	}
+	if autoMove {
+		for a, moved := range c.moveActors(moves) {
+			if moved && moves[a] != nothing {
+				playSound, soundID = true, soundC5
+			}
+		}
+	}
 
-	switch effect {
-	case levelUp, levelLeft, levelDown, levelRight:
-		if c.applyMove(c.levelArea[c.y][c.x] - levelUp) {
+	for a, effect := range effects {
+		act := &c.actors[a]
+		switch effect {
+		case levelUpBox, levelLeftBox, levelDownBox, levelRightBox, levelResetBox, levelNothingBox:
+			if c.moveSequence[c.currentMovePosition] > nothing {
+				// Control slots cannot be put in boxes
+				break
+			}
+			newMove := effect - levelUpBox
+			newFloor := c.moveSequence[c.currentMovePosition] + levelUpBox
+			if newFloor == levelNothingBox {
+				newFloor = levelFloor
+			}
+			c.levelArea[act.y][act.x], c.moveSequence[c.currentMovePosition] =
+				newFloor, newMove
 			playSound, soundID = true, soundC5
+			events = append(events, halfBeatEvent{
+				kind: eventBoxSwitch,
+				toX:  act.x, toY: act.y,
+				floorMove: newFloor - levelUpBox, seqMove: newMove,
+			})
+			c.HideMove = true
+			c.swaps++
+		case levelReset:
+			c.nextMovePosition = 0
+			playSound, soundID = true, soundC5
+		case levelTeleportRound, levelTeleportSquare, levelTeleportCurly:
+			toX, toY, found := c.getTeleportDestination(a)
+			if found && c.isAccessible(toX, toY, nothing) &&
+				c.objects[toY][toX] == objectNone && c.actorAt(toX, toY) < 0 {
+				events = append(events, halfBeatEvent{
+					kind:  eventTeleport,
+					fromX: act.x, fromY: act.y,
+					toX: toX, toY: toY,
+					tile: effect,
+				})
+				c.moveTo(a, toX, toY)
+				playSound, soundID = true, soundE3
+			}
+		case levelKeyA, levelKeyB:
+			c.keys[effect-levelKeyA]++
+			c.levelArea[act.y][act.x] = levelFloor
+			playSound, soundID = true, soundC5
+		case levelChip:
+			c.chips++
+			c.levelArea[act.y][act.x] = levelFloor
+			playSound, soundID = true, soundG4
 		}
-	case levelUpBox, levelLeftBox, levelDownBox, levelRightBox, levelResetBox, levelNothingBox:
-		newMove := c.levelArea[c.y][c.x] - levelUpBox
-		newFloor := c.moveSequence[c.currentMovePosition] + levelUpBox
-		if newFloor == levelNothingBox {
-			newFloor = levelFloor
-		}
-		c.levelArea[c.y][c.x], c.moveSequence[c.currentMovePosition] =
-			newFloor, newMove
-		playSound, soundID, switchBoxes = true, soundC5, true
-		c.HideMove = true
-	case levelReset:
-		c.nextMovePosition = 0
-		playSound, soundID = true, soundC5
 	}
 
+	if c.updateSwitches() && !playSound {
+		playSound, soundID = true, soundE3
+	}
+
+	c.checkHazards()
+
 	return
 }
 
+// Find the other teleporter of the pair an
+// actor stands on.
+func (c character) getTeleportDestination(a int) (x, y int, found bool) {
+	fromX, fromY := c.actors[a].x, c.actors[a].y
+	kind := c.levelArea[fromY][fromX]
+	for y := range c.levelArea {
+		for x := range c.levelArea[y] {
+			if c.levelArea[y][x] == kind && (x != fromX || y != fromY) {
+				return x, y, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
 func (c *character) setHalfBeat() {
 	c.onBeat = false
 }
 
-// If the character has reached the goal position
-// the level is complete.
+// If all the characters have reached goal
+// positions the level is complete.
 func (c character) checkGoal() bool {
-	return c.x == c.levelGoalX && c.y == c.levelGoalY
-}
-
-// Draw the character and the area on screen.
-func (c character) draw(screen *ebiten.Image) {
-
-	drawLevelArea(c.levelArea, c.displayX, c.displayY, screen)
-
-	drawGoal(c.levelGoalX, c.levelGoalY, c.displayX, c.displayY, c.onBeat, screen)
-
-	options := &ebiten.DrawImageOptions{}
-	options.GeoM.Translate(
-		c.displayX+float64(c.x*globalTileSize)-globalTileMargin,
-		c.displayY+float64(c.y*globalTileSize)-globalTileMargin)
-
-	increment := 2
-	if !c.onBeat {
-		increment++
+	if len(c.actors) == 0 {
+		return false
 	}
-	subImageX := (globalTileSize + 2*globalTileMargin) * (levelEmpty + increment)
-
-	screen.DrawImage(tilesImage.SubImage(
-		image.Rect(subImageX, 0,
-			subImageX+(globalTileSize+2*globalTileMargin),
-			globalTileSize+2*globalTileMargin)).(*ebiten.Image),
-		options)
+	for _, act := range c.actors {
+		onGoal := false
+		for _, goal := range c.goals {
+			onGoal = onGoal || (act.x == goal.x && act.y == goal.y)
+		}
+		if !onGoal {
+			return false
+		}
+	}
+	return true
 }
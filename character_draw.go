@@ -0,0 +1,69 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Draw the character and the area on screen.
+func (c character) draw(screen *ebiten.Image) {
+
+	drawLevelArea(c.levelArea, c.displayX, c.displayY, screen)
+
+	drawObjects(c.objects, c.displayX, c.displayY, screen)
+
+	for _, goal := range c.goals {
+		drawGoal(goal.x, goal.y, c.displayX, c.displayY, c.onBeat, screen)
+	}
+
+	increment := 2
+	if !c.onBeat {
+		increment++
+	}
+	subImageX := (globalTileSize + 2*globalTileMargin) * (levelEmpty + increment)
+	characterImage := tilesImage.SubImage(
+		image.Rect(subImageX, 0,
+			subImageX+(globalTileSize+2*globalTileMargin),
+			globalTileSize+2*globalTileMargin)).(*ebiten.Image)
+
+	for _, act := range c.actors {
+		options := &ebiten.DrawImageOptions{}
+		options.GeoM.Translate(
+			c.displayX+float64(act.x*globalTileSize)-globalTileMargin,
+			c.displayY+float64(act.y*globalTileSize)-globalTileMargin)
+		screen.DrawImage(characterImage, options)
+	}
+
+	for _, hzd := range c.hazards {
+		drawHazard(c.displayX+float64(hzd.x*globalTileSize), c.displayY+float64(hzd.y*globalTileSize), c.onBeat, screen)
+	}
+
+	// Keys held by the characters
+	keyX := float32(c.displayX) + 10
+	for color, numKeys := range c.keys {
+		for key := 0; key < numKeys; key++ {
+			drawKey(keyX, float32(c.displayY)-20, keyColors[color], screen)
+			keyX += 30
+		}
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
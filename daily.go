@@ -0,0 +1,122 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// The daily puzzle: a level generated from the date, so
+// that all players get the same level on a given day and
+// can compare their solutions. Daily puzzles are named
+// from their day, which is enough to generate them again
+// when reading a sharing code.
+type daily struct {
+	active bool
+	day    string
+	level  level
+}
+
+// Names of the daily puzzles are dailyPrefix followed
+// by their day, in dailyDayFormat
+const (
+	dailyPrefix    = "daily-"
+	dailyDayFormat = "2006-01-02"
+)
+
+// Options of the level generator for daily puzzles,
+// changing them changes the puzzles of all days
+var dailyOptions = generatorOptions{
+	width: 9, height: 7,
+	sequenceLen:  3,
+	autoMove:     true,
+	boxes:        true,
+	maxSolutions: 2,
+	maxBeats:     40,
+}
+
+// Number of days during which the daily puzzle of a day
+// can still be found from its name (to watch a sharing
+// code), older ones are not generated again
+const dailyMaxAge = 7
+
+// Daily puzzles already generated, by day
+var dailyCache = map[string]daily{}
+
+// Generate the daily puzzle of a given day, or get
+// it back if it was already generated
+func generateDailyLevel(date time.Time) (d daily, ok bool) {
+	day := date.Format(dailyDayFormat)
+	if d, ok = dailyCache[day]; ok {
+		return
+	}
+	d.day = day
+	seed := int64(date.Year()*10000 + int(date.Month())*100 + date.Day())
+	_, d.level, ok = dailyOptions.generateLevel(rand.New(rand.NewSource(seed)), dailyPrefix+d.day)
+	if ok {
+		dailyCache[day] = d
+	}
+	return
+}
+
+// Find the daily puzzle with a given name, only the
+// puzzles of the last dailyMaxAge days before today, of
+// today and of tomorrow (for players whose day already
+// started) can be found
+func findDailyLevel(name string, today time.Time) (d daily, found bool) {
+	day, isDaily := strings.CutPrefix(name, dailyPrefix)
+	if !isDaily {
+		return
+	}
+	date, err := time.Parse(dailyDayFormat, day)
+	if err != nil {
+		return
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if age := today.Sub(date).Hours() / 24; age < -1 || age > dailyMaxAge {
+		return
+	}
+	return generateDailyLevel(date)
+}
+
+// Get the score of a daily puzzle: 1000 points for a
+// solution at par, minus 100 points for each move and
+// 10 points for each beat above par
+func dailyScore(l level, movesUsed, beats int) int {
+	return max(0, 1000-100*max(0, movesUsed-l.parMoves)-10*max(0, beats-l.parBeats))
+}
+
+// Count the days in a row, up to a given day, where the
+// daily puzzle was solved, from the best scores of each
+// day. The streak is not broken while the puzzle of the
+// given day is not solved yet.
+func dailyStreak(scores map[string]int, today time.Time) (streak int) {
+	day := today
+	if _, solved := scores[day.Format(dailyDayFormat)]; !solved {
+		day = day.AddDate(0, 0, -1)
+	}
+	for {
+		if _, solved := scores[day.Format(dailyDayFormat)]; !solved {
+			return
+		}
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Daily puzzles are the same for a given day, their sharing
+// codes give them back from the day before (in another
+// timezone) to a week after, and solved days make a streak.
+func TestDaily(t *testing.T) {
+	date := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	d, ok := generateDailyLevel(date)
+	again, _ := findDailyLevel("daily-2025-08-01", date.AddDate(0, 0, dailyMaxAge))
+	if !ok || d.day != "2025-08-01" || !reflect.DeepEqual(d.level.area, again.level.area) {
+		t.Fatal("same day gave different daily puzzles")
+	}
+	for _, name := range []string{"daily-2025-07-24", "daily-2025-08-03"} {
+		if _, found := findDailyLevel(name, date); found {
+			t.Fatalf("found %s on %s", name, d.day)
+		}
+	}
+
+	s := solution{version: globalVersion, level: d.level, levelNum: -1, bpm: 80,
+		moves: []int{moveUp, nothing, moveLeft}}
+	for _, today := range []time.Time{date.AddDate(0, 0, -1), date, date.AddDate(0, 0, dailyMaxAge)} {
+		decoded, err := decodeSolution(s.code(), today)
+		if err != nil || decoded.levelNum != -1 || decoded.level.name != d.level.name {
+			t.Fatalf("got level %q (%d) from the code on %s, error %v",
+				decoded.level.name, decoded.levelNum, today.Format(dailyDayFormat), err)
+		}
+	}
+
+	if score := dailyScore(d.level, d.level.parMoves, d.level.parBeats+2); score != 980 {
+		t.Fatalf("got score %d two beats above par, want 980", score)
+	}
+
+	scores := map[string]int{"2025-07-30": 1000, "2025-07-31": 500, "2025-07-28": 800}
+	if streak := dailyStreak(scores, date); streak != 2 {
+		t.Fatalf("got a streak of %d days, want 2", streak)
+	}
+}
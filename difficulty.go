@@ -0,0 +1,89 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+)
+
+// The difficulty of a level, estimated by trying all
+// the loops the player can make for it
+type difficulty struct {
+	solutions levelSolutions
+	score     float64
+	estimated bool
+}
+
+// Number of beats after which a loop is considered as
+// not reaching the goal when estimating difficulties
+const difficultyMaxBeats = 300
+
+// Estimate the difficulty of a level. The score grows with
+// the rarity of the loops reaching the goal (in bits: one
+// loop out of 1024 gives 10), with the number of beats and
+// of box swaps they need, and when they all need nothing
+// slots. Levels that cannot be solved get a score of -1.
+// Levels with a second track are not estimated, as only
+// loops without actions would be tried.
+func estimateDifficulty(l level, withReset bool) (d difficulty) {
+	if l.actionLen > 0 {
+		return
+	}
+	d.estimated = true
+	d.solutions = solveLevel(l, getLoopChoices(withReset), difficultyMaxBeats)
+	if d.solutions.count == 0 {
+		d.score = -1
+		return
+	}
+
+	d.score = math.Log2(float64(d.solutions.total)/float64(d.solutions.count)) +
+		float64(d.solutions.bestBeats)/10 + float64(d.solutions.fewestSwaps)
+	if d.solutions.needsNothing {
+		d.score += 2
+	}
+	return
+}
+
+// Print the estimated difficulty of some levels, the
+// reset move can be used from level firstWithReset on
+func printDifficulties(levels []level, firstWithReset int, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "level\tsolving loops\tbeats\tswaps\tnothing\tdifficulty")
+	for levelNum, l := range levels {
+		d := estimateDifficulty(l, levelNum >= firstWithReset)
+		if !d.estimated {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\tnot estimated (second track)\n", l.name)
+			continue
+		}
+		if d.solutions.count == 0 {
+			fmt.Fprintf(tw, "%s\t0/%d\t-\t-\t-\tunsolvable\n", l.name, d.solutions.total)
+			continue
+		}
+		nothingNeeded := "-"
+		if d.solutions.needsNothing {
+			nothingNeeded = "needed"
+		}
+		fmt.Fprintf(tw, "%s\t%d/%d\t%d\t%d\t%s\t%.1f\n", l.name,
+			d.solutions.count, d.solutions.total, d.solutions.bestBeats,
+			d.solutions.fewestSwaps, nothingNeeded, d.score)
+	}
+	return tw.Flush()
+}
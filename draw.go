@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -20,48 +22,63 @@ package main
 import (
 	"fmt"
 	"image/color"
+	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 func (g *game) Draw(screen *ebiten.Image) {
 
 	screen.Fill(color.RGBA{R: 0xca, G: 0xa0, B: 0x5a, A: 255})
 
-	if g.state == stateTitle {
-		g.title.draw(screen)
-	} else if g.state == stateIntro {
-		g.intro.draw(screen)
-	} else if g.state == stateEnd {
-		g.end.draw(screen)
-	} else {
+	g.scenes.draw(g, screen)
+
+	g.cursor.draw(screen)
+
+	//drawTextAt(fmt.Sprintf("TPS: %f, FPS:%f", ebiten.ActualTPS(), ebiten.ActualFPS()), 0, 0, screen)
+
+}
+
+// Draw the level being played.
+func (g *game) drawLevel(screen *ebiten.Image) {
+	if !g.endless.active && !g.daily.active {
 		if g.level == 0 {
 			drawTuto(screen)
 		}
 
-		if g.level == levelSteps[0] || g.level == levelSteps[1] || g.level == levelSteps[2] {
+		if isTeachingLevel(g.level) && !g.timeAttack.active {
 			drawLearningInfo(screen, g.level)
 		}
-
-		g.character.draw(screen)
-
-		g.buttonSet.draw(
-			g.character.moveSequence,
-			g.character.currentMovePosition,
-			g.character.HideMove,
-			g.state == statePlaySequence,
-			!g.soundEngine.mute,
-			screen)
-
-		g.boxSwitcher.draw(screen)
-
-		g.drawLevelInfo(screen)
 	}
 
-	g.cursor.draw(screen)
+	g.camera.check(g.character, g.currentLevelNum())
+	g.camera.world.Clear()
+	g.character.draw(g.camera.world)
+	for _, t := range g.teleportEffects {
+		t.draw(g.camera.world)
+	}
+	g.camera.draw(screen)
 
-	//drawTextAt(fmt.Sprintf("TPS: %f, FPS:%f", ebiten.ActualTPS(), ebiten.ActualFPS()), 0, 0, screen)
+	currentMovePosition, inPlay := g.character.currentMovePosition, g.phase == phasePlaySequence
+	if g.phase == phaseRecordSequence {
+		currentMovePosition, inPlay = g.recorder.currentSlot(), true
+	}
+	g.buttonSet.draw(
+		g.character.moveSequence,
+		currentMovePosition,
+		g.character.actionSequence,
+		g.character.currentActionPosition,
+		g.character.HideMove,
+		inPlay,
+		!g.soundEngine.mute,
+		screen)
+
+	for _, b := range g.boxSwitchers {
+		b.draw(screen)
+	}
 
+	g.drawLevelInfo(screen)
 }
 
 func (g game) drawLevelInfo(screen *ebiten.Image) {
@@ -69,17 +86,54 @@ func (g game) drawLevelInfo(screen *ebiten.Image) {
 	//if g.level == 0 {
 	//	drawTextAt("Cybernetic Unit Benchmark ver. 0.1", 20, 10, screen)
 	//} else {
-	text := fmt.Sprintf("C.U.B version 0.%d", g.evolutionStep)
-	if g.evolutionSubStep > 0 {
-		text = fmt.Sprintf("%s.%d", text, g.evolutionSubStep)
+	var text string
+	if g.endless.active {
+		text = fmt.Sprintf("Endless level %d - streak %d (best %d)",
+			g.endless.levelNum, g.endless.streak, max(g.endless.streak, g.progress.EndlessBest))
+	} else if g.daily.active {
+		text = "Daily puzzle " + g.daily.day
+	} else if g.timeAttack.active {
+		text = fmt.Sprintf("Time attack %d/%d - %s", g.level+1, len(levelSet), formatFrames(g.timeAttack.frames))
+	} else {
+		text = fmt.Sprintf("C.U.B version 0.%d", g.evolutionStep)
+		if g.evolutionSubStep > 0 {
+			text = fmt.Sprintf("%s.%d", text, g.evolutionSubStep)
+		}
+		text = fmt.Sprintf("%s - experiment %d/%d", text, g.level+1, len(levelSet))
 	}
-	text = fmt.Sprintf("%s - experiment %d/%d", text, g.level+1, len(levelSet))
 	drawTextAt(text, 20, 10, screen)
 	//}
 
+	// Chips collected, right after the level
+	if numChips := g.currentLevel().numChips; numChips > 0 {
+		chipsText := fmt.Sprintf("Chips %d/%d", g.character.chips, numChips)
+		drawCenteredText(chipsText, 30+textWidth(text, ocpFace)+textWidth(chipsText, smallFace)/2,
+			10+ocpFace.Size/2, smallFace, screen)
+	}
+
 	text = fmt.Sprintf("Freq. %d", g.bpm)
 	drawTextAt(text, 650, 10, screen)
 
+	if g.phase == phaseRecordSequence {
+		text = "Record on the beat: " + judgementNames[g.recorder.judgement]
+		if countIn := g.recorder.countIn(); countIn > 0 {
+			text = fmt.Sprintf("Get ready: %d", countIn)
+		}
+		drawTextAt(text, 60, 50, screen)
+	} else if g.hints.asked && g.phase == phaseSetupSequence {
+		drawTextAt("Looking for a hint...", 60, 50, screen)
+	} else if g.hints.unavailable() && g.phase == phaseSetupSequence {
+		drawTextAt("No hint for this level", 60, 50, screen)
+	} else if g.endless.active {
+		drawTextAt("Esc: menu - N: skip level", 60, 50, screen)
+	} else if g.replaying {
+		drawTextAt("Replay - restart to stop", 60, 50, screen)
+	} else if g.lastCode != "" && g.phase == phaseSetupSequence {
+		drawTextAt("Last code: "+g.lastCode, 60, 50, screen)
+	} else if g.phase == phaseSetupSequence && g.currentLevelNum() != 0 {
+		drawTextAt("R: record the loop on the beat", 60, 50, screen)
+	}
+
 }
 
 func drawTuto(screen *ebiten.Image) {
@@ -107,3 +161,48 @@ func drawLearningInfo(screen *ebiten.Image, levelNum int) {
 
 	drawTextAt(text, 115, 400, screen)
 }
+
+// Draw a star centered at (x, y), filled or not.
+func drawStar(x, y, radius float32, filled bool, screen *ebiten.Image) {
+
+	var path vector.Path
+	for point := 0; point < 10; point++ {
+		pointRadius := radius
+		if point%2 == 1 {
+			pointRadius = radius * 0.45
+		}
+		angle := float64(point)*math.Pi/5 - math.Pi/2
+		pointX := x + pointRadius*float32(math.Cos(angle))
+		pointY := y + pointRadius*float32(math.Sin(angle))
+		if point == 0 {
+			path.MoveTo(pointX, pointY)
+		} else {
+			path.LineTo(pointX, pointY)
+		}
+	}
+	path.Close()
+
+	var vertices []ebiten.Vertex
+	var indices []uint16
+	if filled {
+		vertices, indices = path.AppendVerticesAndIndicesForFilling(nil, nil)
+	} else {
+		vertices, indices = path.AppendVerticesAndIndicesForStroke(nil, nil,
+			&vector.StrokeOptions{Width: 4, LineJoin: vector.LineJoinRound})
+	}
+
+	for pos := range vertices {
+		vertices[pos].SrcX = 1
+		vertices[pos].SrcY = 1
+		vertices[pos].ColorR = 0x8b / float32(0xff)
+		vertices[pos].ColorG = 0x40 / float32(0xff)
+		vertices[pos].ColorB = 0x49 / float32(0xff)
+		vertices[pos].ColorA = 1
+	}
+
+	options := &ebiten.DrawTrianglesOptions{AntiAlias: true}
+	if filled {
+		options.FillRule = ebiten.FillRuleNonZero
+	}
+	screen.DrawTriangles(vertices, indices, whiteImage, options)
+}
@@ -0,0 +1,154 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// The endless mode: generated levels one after the other,
+// harder and played faster as the player goes on. The
+// streak counts the levels solved without skipping any.
+// Each level is generated while the previous one is
+// played, as the larger ones take some time to find.
+type endless struct {
+	active   bool
+	levelNum int
+	streak   int
+	current  endlessLevel
+	next     chan endlessLevel
+	done     chan struct{}
+}
+
+// A level of the endless mode, with the options
+// used to generate it
+type endlessLevel struct {
+	level   level
+	options generatorOptions
+}
+
+// Speed of the music in the endless mode: it starts at
+// endlessStartBPM and goes up by endlessBPMStep at
+// each level, up to globalMaxBPM
+const (
+	endlessStartBPM = 60
+	endlessBPMStep  = 5
+)
+
+// Get the options used to generate the n-th level of the
+// endless mode (starting at 1): levels get larger, use
+// longer sequences and more mechanics, and have fewer
+// solutions as n grows
+func endlessOptions(levelNum int) (o generatorOptions) {
+	o = generatorOptions{
+		width: 7, height: 5,
+		sequenceLen:  2,
+		maxSolutions: 3,
+		maxBeats:     30,
+	}
+	if levelNum > 3 {
+		o.width, o.height = 8, 6
+		o.sequenceLen = 3
+		o.autoMove = true
+		o.maxSolutions = 2
+	}
+	if levelNum > 6 {
+		o.width, o.height = 9, 7
+		o.boxes = true
+		o.maxSolutions = 1
+		o.maxBeats = 40
+	}
+	if levelNum > 10 {
+		o.sequenceLen = 4
+		o.maxBeats = 60
+	}
+	if levelNum > 15 {
+		o.reset = true
+	}
+	return
+}
+
+// Generate the n-th level of the endless mode, falling back
+// to the options of easier levels when no level is found,
+// ok is false if no level is found even with the easiest
+// options
+func generateEndlessLevel(rng *rand.Rand, levelNum int) (e endlessLevel, ok bool) {
+	name := fmt.Sprintf("endless%d", levelNum)
+	for optionsNum := levelNum; optionsNum > 0; optionsNum-- {
+		e.options = endlessOptions(optionsNum)
+		if _, e.level, ok = e.options.generateLevel(rng, name); ok {
+			return
+		}
+	}
+	return
+}
+
+// Start a new run of the endless mode, the same seed
+// always gives the same levels
+func newEndless(seed int64) (e endless) {
+	e.active = true
+	e.next = make(chan endlessLevel)
+	e.done = make(chan struct{})
+	go func(rng *rand.Rand, next chan<- endlessLevel, done <-chan struct{}) {
+		for levelNum := 1; ; levelNum++ {
+			// The generator goes on with new random
+			// numbers when trying again
+			l, ok := generateEndlessLevel(rng, levelNum)
+			for !ok {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				l, ok = generateEndlessLevel(rng, levelNum)
+			}
+			select {
+			case next <- l:
+			case <-done:
+				return
+			}
+		}
+	}(rand.New(rand.NewSource(seed)), e.next, e.done)
+	return
+}
+
+// Go to the next level if it is already generated,
+// ready is false if it is not
+func (e *endless) nextLevel() (ready bool) {
+	select {
+	case e.current = <-e.next:
+		e.levelNum++
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop the endless mode
+func (e *endless) stop() {
+	if e.active {
+		close(e.done)
+		e.active = false
+	}
+}
+
+// Get the speed of the music for the current level
+func (e endless) bpm() int {
+	return min(endlessStartBPM+(e.levelNum-1)*endlessBPMStep, globalMaxBPM)
+}
@@ -0,0 +1,59 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// The scene shown while the next level of the endless
+// mode is being generated, the music goes on
+type endlessWaitScene struct{}
+
+func (s *endlessWaitScene) enter(g *game) {}
+
+func (s *endlessWaitScene) exit(g *game) {}
+
+// Play the next level as soon as it is generated,
+// Escape goes back to the title screen
+func (s *endlessWaitScene) update(g *game) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.quitToTitle()
+		g.soundEngine.nextSounds[soundBack] = true
+		return
+	}
+	if g.endless.nextLevel() {
+		g.playEndlessLevel()
+		g.soundEngine.nextSounds[soundGo] = true
+	}
+}
+
+func (s *endlessWaitScene) onBeat(g *game) {}
+
+func (s *endlessWaitScene) onHalfBeat(g *game) {}
+
+func (s *endlessWaitScene) draw(g *game, screen *ebiten.Image) {
+	drawCenteredText(fmt.Sprintf("Generating endless level %d...", g.endless.levelNum+1),
+		globalScreenWidth/2, globalScreenHeight/2, ocpFace, screen)
+	drawCenteredText("Esc: quit", globalScreenWidth/2, globalScreenHeight/2+50, smallFace, screen)
+}
@@ -0,0 +1,52 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// The endless mode serves solvable levels, the same seed
+// gives the same levels, and the music gets faster.
+func TestEndless(t *testing.T) {
+	e, again := newEndless(1), newEndless(1)
+	defer e.stop()
+	defer again.stop()
+
+	for levelNum := 1; levelNum <= 3; levelNum++ {
+		for !e.nextLevel() {
+			time.Sleep(time.Millisecond)
+		}
+		for !again.nextLevel() {
+			time.Sleep(time.Millisecond)
+		}
+		l := e.current.level
+		s := solveLevel(l, e.current.options.getChoices(), e.current.options.maxBeats)
+		if s.count == 0 || s.bestBeats != l.parBeats {
+			t.Fatalf("level %d: got %d solutions, best in %d beats, want par %d", levelNum, s.count, s.bestBeats, l.parBeats)
+		}
+		if !reflect.DeepEqual(l.area, again.current.level.area) {
+			t.Fatalf("level %d: same seed gave different levels", levelNum)
+		}
+		if e.bpm() != endlessStartBPM+(levelNum-1)*endlessBPMStep {
+			t.Fatalf("level %d: got %d BPM", levelNum, e.bpm())
+		}
+	}
+}
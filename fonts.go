@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -31,6 +33,7 @@ import (
 var ocpRegular_ttf []byte
 var ocpFaceSource *text.GoTextFaceSource
 var ocpFace *text.GoTextFace
+var smallFace *text.GoTextFace
 
 func loadFonts() {
 	s, err := text.NewGoTextFaceSource(bytes.NewReader(ocpRegular_ttf))
@@ -43,6 +46,11 @@ func loadFonts() {
 		Source: ocpFaceSource,
 		Size:   24,
 	}
+
+	smallFace = &text.GoTextFace{
+		Source: ocpFaceSource,
+		Size:   14,
+	}
 }
 
 func drawTextAt(theText string, x, y float64, screen *ebiten.Image) (height float64) {
@@ -61,3 +69,28 @@ func drawTextAt(theText string, x, y float64, screen *ebiten.Image) (height floa
 	_, height = text.Measure(theText, ocpFace, ocpFace.Size*1.5)
 	return
 }
+
+// Draw a single line of text centered at (x, y)
+// with a given face
+func drawCenteredText(theText string, x, y float64, face *text.GoTextFace, screen *ebiten.Image) {
+	width, height := text.Measure(theText, face, 0)
+	x -= width / 2
+	y -= height / 2
+
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(x, y+1)
+	op.ColorScale.ScaleWithColor(color.RGBA{R: 0x54, G: 0x33, B: 0x44, A: 255})
+	text.Draw(screen, theText, face, op)
+
+	op = &text.DrawOptions{}
+	op.GeoM.Translate(x, y)
+	op.ColorScale.ScaleWithColor(color.RGBA{R: 0x8b, G: 0x40, B: 0x49, A: 255})
+	text.Draw(screen, theText, face, op)
+}
+
+// Get the width of a single line of text drawn
+// with a given face
+func textWidth(theText string, face *text.GoTextFace) float64 {
+	width, _ := text.Measure(theText, face, 0)
+	return width
+}
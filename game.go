@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -17,8 +19,19 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 package main
 
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
 type game struct {
-	state            int
+	scenes           sceneManager
+	phase            int
 	soundEngine      soundEngine
 	sequencer        sequencer
 	character        character
@@ -32,53 +45,525 @@ type game struct {
 	evolutionSubStep int
 	bpm              int
 	oldBpm           int
-	boxSwitcher      boxSwitcher
+	boxSwitchers     []boxSwitcher
+	teleportEffects  []teleportEffect
+	camera           camera
+	replaying        bool
+	replayFromResult bool
+	lastSolution     solution
+	lastCode         string
+	replayDir        string
+	result           levelResult
+	progress         progress
+	endless          endless
+	daily            daily
+	timeAttack       timeAttack
+	recorder         rhythmRecorder
+	hints            hints
+	settings         settings
+	telemetry        *telemetry
+	attempt          attempt
 }
 
-// Possible game states
+// Possible phases of a level being played
 const (
-	stateSetupSequence int = iota
-	statePlaySequence
-	stateTitle
-	stateIntro
-	stateEnd
+	phaseSetupSequence int = iota
+	phasePlaySequence
+	phaseRecordSequence
 )
 
 func newGame() (g game) {
 	loadFonts()
 	loadImages()
 	initLevels()
+	g.progress = loadProgress()
+	g.settings = loadSettings()
 	g.soundEngine = newSoundEngine()
+	g.applySettings()
 	g.reset()
 	g.sequencer = newSequencer(g.bpm, 16)
 	return
 }
 
 func (g *game) reset() {
+	g.endless.stop()
+	g.daily.active = false
+	g.timeAttack.active = false
 	g.intro = setupIntro()
 	g.end = setupEnd()
 	g.level = 0
 	g.evolutionStep = 1
 	g.evolutionSubStep = 0
 	g.setLevel()
-	g.state = stateTitle
-	g.bpm = globalDefaultBPM
+	g.attempt.inProgress = false
+	g.scenes.set(g, &titleScene{})
+	g.bpm = g.settings.DefaultBPM
+	g.replaying = false
+	g.lastCode = ""
 }
 
 func (g *game) setLevel() {
-	if g.evolutionStep-1 < len(levelSteps) {
-		if g.level >= levelSteps[g.evolutionStep-1] {
+	if !g.endless.active && !g.daily.active {
+		if g.evolutionStep-1 < len(levelSteps) {
+			if g.level >= levelSteps[g.evolutionStep-1] {
+				g.evolutionStep++
+				g.evolutionSubStep = 0
+			}
+		}
+		if g.level >= len(levelSet) {
+			g.scenes.set(g, &introScene{end: true})
+			g.bpm = g.settings.DefaultBPM
+			g.sequencer.setBpm(g.bpm)
+			return
+		}
+	}
+	g.setUpLevel()
+	g.scenes.set(g, &levelScene{})
+	g.startAttempt()
+}
+
+// Set up the current level from scratch, with the loop
+// given to start with in the levels of the campaign that
+// teach something new, and without any hint.
+func (g *game) setUpLevel() {
+	g.character.reset(g.currentLevel(), true)
+	g.phase = phaseSetupSequence
+	g.buttonSet.setupButtons(len(g.character.moveSequence), len(g.character.actionSequence), g.withReset(), g.withControls())
+	g.hints = hints{}
+
+	if !g.endless.active && !g.daily.active && isTeachingLevel(g.level) {
+		for pos := 0; pos < len(g.character.moveSequence); pos++ {
+			if pos < 3 {
+				g.character.moveSequence[pos] = moveRight
+			} else {
+				g.character.moveSequence[pos] = moveDown
+			}
+		}
+	}
+}
+
+// Get the level being played, from the campaign, from
+// the endless mode or the daily puzzle
+func (g game) currentLevel() level {
+	if g.endless.active {
+		return g.endless.current.level
+	}
+	if g.daily.active {
+		return g.daily.level
+	}
+	return levelSet[g.level]
+}
+
+// Get a number identifying the level being played,
+// the levels of the endless mode come after the
+// ones of the campaign and the daily puzzle is -1
+func (g game) currentLevelNum() int {
+	if g.endless.active {
+		return len(levelSet) + g.endless.levelNum
+	}
+	if g.daily.active {
+		return -1
+	}
+	return g.level
+}
+
+// Tell if the reset move can be used in the
+// level being played
+func (g game) withReset() bool {
+	if g.endless.active {
+		return g.endless.current.options.reset
+	}
+	if g.daily.active {
+		return dailyOptions.reset
+	}
+	return g.level >= levelStepReset
+}
+
+// Tell if the control slots (skip, repeat and jump)
+// can be used in the level being played, generated
+// levels are made without them
+func (g game) withControls() bool {
+	if g.endless.active || g.daily.active {
+		return false
+	}
+	return g.level >= levelStepControls
+}
+
+// Show the result of the level that was just solved
+// and record it in the progress of the player.
+func (g *game) showResult() {
+	if g.endless.active {
+		g.showEndlessResult()
+		return
+	}
+	if g.daily.active {
+		g.showDailyResult()
+		return
+	}
+	if g.timeAttack.active {
+		g.nextTimeAttackLevel()
+		return
+	}
+	l := levelSet[g.level]
+	g.result = newLevelResult(fmt.Sprintf("Experiment %d/%d completed.", g.level+1, len(levelSet)), l, g.character)
+	g.result.hints = g.hints.shown
+	g.result.improved = g.progress.setStars(l.name, g.result.stars)
+	g.result.bestStars = g.progress.Stars[l.name]
+	newChips := false
+	if l.numChips > 0 && g.result.chips == l.numChips {
+		newChips = g.progress.setAllChips(l.name)
+	}
+	if g.result.improved || newChips {
+		g.progress.save()
+	}
+	g.scenes.set(g, &resultScene{})
+}
+
+// Stop all the visual effects.
+func (g *game) resetEffects() {
+	g.boxSwitchers = g.boxSwitchers[:0]
+	g.teleportEffects = g.teleportEffects[:0]
+}
+
+// Update the visual effects, forgetting the ones
+// that are over.
+func (g *game) updateEffects() {
+	boxSwitchers := g.boxSwitchers[:0]
+	for _, b := range g.boxSwitchers {
+		b.update()
+		if !b.done() {
+			boxSwitchers = append(boxSwitchers, b)
+		}
+	}
+	g.boxSwitchers = boxSwitchers
+
+	teleportEffects := g.teleportEffects[:0]
+	for _, t := range g.teleportEffects {
+		t.update()
+		if !t.done() {
+			teleportEffects = append(teleportEffects, t)
+		}
+	}
+	g.teleportEffects = teleportEffects
+}
+
+// Start playing the sequence of moves.
+func (g *game) playSequence() {
+	g.attempt.Plays++
+	g.soundEngine.nextSounds[soundGo] = true
+	g.phase = phasePlaySequence
+	g.buttonSet.setFirstLoop()
+	g.character.storeMoves()
+}
+
+// Start recording the sequence of moves on the beat,
+// it is played once recorded.
+func (g *game) startRecording() {
+	g.recorder = newRhythmRecorder(len(g.character.moveSequence))
+	copy(g.character.moveSequence, g.recorder.moves)
+	g.phase = phaseRecordSequence
+}
+
+// Reveal one more slot of a solution of the current level,
+// putting all the revealed moves and actions in the
+// sequences. Hints used are recorded in the progress. If
+// the solution is still being searched for, the hint is
+// shown once it is found.
+func (g *game) showHint() {
+	l := g.currentLevel()
+	if !g.hints.searched {
+		g.hints.search(l, g.withReset())
+	}
+	g.hints.asked = g.hints.searching()
+	if g.hints.asked {
+		return
+	}
+	if !g.hints.next() {
+		g.soundEngine.nextSounds[soundBlip] = true
+		return
+	}
+	g.hints.apply(g.character.moveSequence, g.character.actionSequence)
+	g.attempt.Hints++
+	g.progress.addHint(l.name)
+	g.progress.save()
+	g.soundEngine.nextSounds[soundBlip2] = true
+}
+
+// Try the level that was just solved once more,
+// starting from the solution that was found.
+func (g *game) retryLevel() {
+	g.character.reset(g.currentLevel(), false)
+	g.character.restoreMoves()
+	g.buttonSet.setupButtons(len(g.character.moveSequence), len(g.character.actionSequence), g.withReset(), g.withControls())
+	g.resetEffects()
+	g.phase = phaseSetupSequence
+	g.scenes.set(g, &levelScene{})
+	g.startAttempt()
+}
+
+// Go to the next level, changing the speed of the music
+// following the rule of the game mode (see bpmRule).
+func (g *game) nextLevel() {
+	if g.endless.active {
+		g.nextEndlessLevel()
+		return
+	}
+	rule := bpmRule(campaignBPM)
+	frames := 0
+	if g.timeAttack.active {
+		rule = timeAttackBPM
+		frames = g.timeAttack.splits[len(g.timeAttack.splits)-1]
+	}
+	g.bpm, g.oldBpm = nextLevelBPM(g.level, g.bpm, g.oldBpm, g.settings.DefaultBPM, rule, frames)
+	g.sequencer.setBpm(g.bpm)
+
+	g.level++
+	g.evolutionSubStep++
+	g.setLevel()
+}
+
+// Set the version of C.U.B from the level of the
+// campaign being played.
+func (g *game) setEvolution() {
+	g.evolutionStep = 1
+	g.evolutionSubStep = g.level
+	for _, step := range levelSteps {
+		if g.level >= step {
 			g.evolutionStep++
-			g.evolutionSubStep = 0
+			g.evolutionSubStep = g.level - step
+		}
+	}
+}
+
+// Watch a solution play from the start of its level.
+func (g *game) startReplay(s solution) {
+	if s.levelNum < 0 {
+		g.daily = daily{
+			active: true,
+			day:    strings.TrimPrefix(s.level.name, dailyPrefix),
+			level:  s.level,
+		}
+	} else {
+		g.level = s.levelNum
+		g.setEvolution()
+	}
+	g.character.reset(g.currentLevel(), true)
+	copy(g.character.moveSequence, s.moves)
+	copy(g.character.actionSequence, s.actions)
+	g.character.storeMoves()
+	g.buttonSet.setupButtons(len(g.character.moveSequence), len(g.character.actionSequence), g.withReset(), g.withControls())
+	g.buttonSet.setFirstLoop()
+	g.resetEffects()
+	g.bpm = s.bpm
+	g.sequencer.setBpm(g.bpm)
+	g.phase = phasePlaySequence
+	g.scenes.set(g, &levelScene{})
+	g.replaying = true
+}
+
+// Go back to the title screen (or to the result of
+// the level) after watching a solution.
+func (g *game) endReplay() {
+	if g.character.checkGoal() {
+		g.soundEngine.nextSounds[soundSuccess] = true
+	} else {
+		g.soundEngine.nextSounds[soundBack] = true
+	}
+	g.resetEffects()
+	g.replaying = false
+	if g.replayFromResult {
+		g.replayFromResult = false
+		g.scenes.set(g, &resultScene{fromReplay: true})
+		return
+	}
+	g.reset()
+	g.sequencer.setBpm(g.bpm)
+}
+
+// Record the sequence of moves that just solved the current
+// level, saving it to a replay file if asked to.
+func (g *game) recordSolution() {
+	s := solution{
+		version:  globalVersion,
+		level:    g.currentLevel(),
+		levelNum: g.currentLevelNum(),
+		bpm:      g.bpm,
+		moves:    make([]int, len(g.character.originalMoveSequence)),
+	}
+	copy(s.moves, g.character.originalMoveSequence)
+	if len(g.character.originalActionSequence) > 0 {
+		s.actions = make([]int, len(g.character.originalActionSequence))
+		copy(s.actions, g.character.originalActionSequence)
+	}
+	g.lastSolution = s
+	g.lastCode = s.code()
+
+	if g.replayDir != "" {
+		path := filepath.Join(g.replayDir, s.level.name+".cubreplay")
+		if err := s.writeFile(path); err != nil {
+			log.Print("Cannot save replay: ", err)
 		}
 	}
-	if g.level >= len(levelSet) {
-		g.state = stateEnd
-		g.bpm = globalDefaultBPM
-		g.sequencer.setBpm(g.bpm)
+}
+
+// Start a run of the endless mode.
+func (g *game) startEndless() {
+	g.endless = newEndless(time.Now().UnixNano())
+	g.nextEndlessLevel()
+}
+
+// Go to the next level of the endless mode, or wait
+// for it if it is still being generated.
+func (g *game) nextEndlessLevel() {
+	g.resetEffects()
+	if !g.endless.nextLevel() {
+		g.scenes.set(g, &endlessWaitScene{})
+		return
+	}
+	g.playEndlessLevel()
+}
+
+// Play the level of the endless mode that was just
+// generated, with faster music.
+func (g *game) playEndlessLevel() {
+	g.bpm = g.endless.bpm()
+	g.sequencer.setBpm(g.bpm)
+	g.setLevel()
+}
+
+// Give up the current level of the endless mode,
+// which ends the streak.
+func (g *game) skipEndlessLevel() {
+	g.endAttempt(outcomeSkipped)
+	g.endless.streak = 0
+	g.nextEndlessLevel()
+}
+
+// Leave the level being played, in the campaign or in
+// any other mode, and go back to the title screen.
+func (g *game) quitToTitle() {
+	g.endAttempt(outcomeQuit)
+	g.resetEffects()
+	g.reset()
+	g.sequencer.setBpm(g.bpm)
+}
+
+// Show the result of the level of the endless mode
+// that was just solved and record the streak.
+func (g *game) showEndlessResult() {
+	g.endless.streak++
+	g.result = newLevelResult(
+		fmt.Sprintf("Endless level %d completed.", g.endless.levelNum),
+		g.endless.current.level, g.character)
+	g.result.hints = g.hints.shown
+	g.result.setEndless(g.endless.streak, g.progress.EndlessBest)
+	if g.progress.setEndlessBest(g.endless.streak) {
+		g.progress.save()
+	}
+	g.scenes.set(g, &resultScene{})
+}
+
+// Start the daily puzzle of today, returns false if
+// it could not be generated.
+func (g *game) startDaily() bool {
+	d, ok := generateDailyLevel(time.Now())
+	if !ok {
+		log.Print("Cannot generate the daily puzzle")
+		return false
+	}
+	g.daily = d
+	g.daily.active = true
+	g.setLevel()
+	return true
+}
+
+// Show the result of the daily puzzle that was just
+// solved and record its score.
+func (g *game) showDailyResult() {
+	g.result = newLevelResult(fmt.Sprintf("Daily puzzle %s solved.", g.daily.day),
+		g.daily.level, g.character)
+	g.result.hints = g.hints.shown
+	score := dailyScore(g.daily.level, g.result.movesUsed, g.result.beats)
+	bestScore, solved := g.progress.Daily[g.daily.day]
+	if g.progress.setDailyScore(g.daily.day, score) {
+		g.progress.save()
+	}
+	g.result.setDaily(score, bestScore, solved,
+		dailyStreak(g.progress.Daily, time.Now()), g.lastCode)
+	g.scenes.set(g, &resultScene{})
+}
+
+// Start a time attack from the first level.
+func (g *game) startTimeAttack() {
+	g.timeAttack = timeAttack{active: true}
+	g.bpm = g.settings.DefaultBPM
+	g.sequencer.setBpm(g.bpm)
+	g.setLevel()
+}
+
+// Record the split time of the level of the time attack
+// that was just solved and go to the next one, or show
+// the times once the last level is solved.
+func (g *game) nextTimeAttackLevel() {
+	g.timeAttack.split()
+	g.resetEffects()
+	if g.level+1 < len(levelSet) {
+		g.nextLevel()
 		return
 	}
-	g.character.reset(levelSet[g.level], true)
-	g.state = stateSetupSequence
-	g.buttonSet.setupButtons(len(g.character.moveSequence))
+
+	names := make([]string, len(levelSet))
+	for levelNum, l := range levelSet {
+		names[levelNum] = l.name
+	}
+	bestFrames := g.progress.TimeAttackBest
+	improved := g.progress.setTimeAttackBest(g.timeAttack.frames, g.timeAttack.splits)
+	if improved {
+		g.progress.save()
+	}
+	g.result = newTimeAttackResult(g.timeAttack.frames, bestFrames, improved, g.timeAttack.splits, names)
+	g.scenes.set(g, &resultScene{})
+}
+
+// Apply the settings of the player to the sounds
+// and the window.
+func (g *game) applySettings() {
+	g.soundEngine.setVolume(g.settings.Volume)
+	ebiten.SetFullscreen(g.settings.Fullscreen)
+}
+
+// Change one of the settings of the player from the
+// options menu, and save it.
+func (g *game) changeSetting(setting, steps int) {
+	g.settings.change(setting, steps)
+	g.settings.save()
+	g.applySettings()
+}
+
+// Pause the level being played, the music, the clock
+// and the simulation stay frozen until it is resumed.
+func (g *game) pause() {
+	g.scenes.push(g, &pauseScene{})
+}
+
+// Start the current level again from scratch, like
+// when it was set up, in the same attempt.
+func (g *game) restartLevel() {
+	g.attempt.Restarts++
+	g.resetEffects()
+	g.setUpLevel()
+}
+
+// Leave the current level of the campaign and go to
+// another one, at the default speed of the music (or
+// the speed of the level if it teaches something new).
+func (g *game) selectLevel(levelNum int) {
+	g.endAttempt(outcomeQuit)
+	g.resetEffects()
+	g.level = levelNum
+	g.setEvolution()
+	g.oldBpm = g.settings.DefaultBPM
+	g.bpm = campaignBPM(g.level, g.bpm, g.oldBpm, 0)
+	g.sequencer.setBpm(g.bpm)
+	g.setLevel()
 }
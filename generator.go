@@ -0,0 +1,192 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options of the level generator: the size of the levels
+// (outer walls included), the length of their sequence,
+// the mechanics they use, and what makes a level good
+// enough to be kept: at most maxSolutions loops reach
+// the goal, the best one in at most maxBeats beats.
+type generatorOptions struct {
+	width, height int
+	sequenceLen   int
+	autoMove      bool
+	boxes         bool
+	reset         bool
+	maxSolutions  int
+	maxBeats      int
+}
+
+// Default options of the level generator
+var defaultGeneratorOptions = generatorOptions{
+	width: 9, height: 7,
+	sequenceLen:  3,
+	autoMove:     true,
+	boxes:        true,
+	maxSolutions: 1,
+	maxBeats:     40,
+}
+
+// Set the mechanics used by generated levels from a comma
+// separated list of names (automove, boxes and reset), the
+// other mechanics are not used
+func (o *generatorOptions) setMechanics(list string) error {
+	o.autoMove, o.boxes, o.reset = false, false, false
+	for _, name := range strings.Split(list, ",") {
+		switch strings.TrimSpace(name) {
+		case "automove":
+			o.autoMove = true
+		case "boxes":
+			o.boxes = true
+		case "reset":
+			o.reset = true
+		case "":
+		default:
+			return fmt.Errorf("unknown mechanic %q", name)
+		}
+	}
+	return nil
+}
+
+// Number of candidates tried before giving up on
+// generating a level
+const generatorMaxTries = 5000
+
+// Glyphs of the things added by each mechanic
+var (
+	autoMoveGlyphs = []byte("udlr")
+	boxGlyphs      = []byte("UDLRN")
+	resetGlyphs    = []byte("bB")
+)
+
+// Get the moves a loop can use in generated levels
+func (o generatorOptions) getChoices() (choices []int) {
+	return getLoopChoices(o.reset)
+}
+
+// Draw a random level, in the format of the files in
+// levels/ (without metadata)
+func (o generatorOptions) drawCandidate(rng *rand.Rand) []byte {
+	var glyphs []byte
+	if o.autoMove {
+		glyphs = append(glyphs, autoMoveGlyphs...)
+	}
+	if o.boxes {
+		glyphs = append(glyphs, boxGlyphs...)
+	}
+	if o.reset {
+		glyphs = append(glyphs, resetGlyphs...)
+	}
+
+	area := make([][]byte, o.height)
+	var floor [][2]int
+	for y := range area {
+		area[y] = bytes.Repeat([]byte{'#'}, o.width)
+		if y == 0 || y == o.height-1 {
+			continue
+		}
+		for x := 1; x < o.width-1; x++ {
+			switch {
+			case rng.Intn(5) == 0:
+				// Keep the wall
+			case len(glyphs) > 0 && rng.Intn(8) == 0:
+				area[y][x] = glyphs[rng.Intn(len(glyphs))]
+			default:
+				area[y][x] = '.'
+				floor = append(floor, [2]int{x, y})
+			}
+		}
+	}
+
+	if len(floor) >= 2 {
+		start := rng.Intn(len(floor))
+		goal := rng.Intn(len(floor) - 1)
+		if goal >= start {
+			goal++
+		}
+		area[floor[start][1]][floor[start][0]] = 's'
+		area[floor[goal][1]][floor[goal][0]] = 'g'
+	}
+
+	text := []byte{byte('0' + o.sequenceLen)}
+	for _, line := range area {
+		text = append(text, '\n')
+		text = append(text, line...)
+	}
+	return text
+}
+
+// Generate a level matching the options. Solving loops are
+// counted as long as they reach the goal before
+// difficultyMaxBeats, even when they are slower than the
+// best one allowed. The text of the level includes its
+// par values. ok is false if no level was found after
+// generatorMaxTries candidates.
+func (o generatorOptions) generateLevel(rng *rand.Rand, name string) (text []byte, l level, ok bool) {
+	for try := 0; try < generatorMaxTries; try++ {
+		text = o.drawCandidate(rng)
+		l = readLevel(name, text)
+		if len(l.starts) != 1 || len(l.goals) != 1 {
+			continue
+		}
+		s := solveLevel(l, o.getChoices(), difficultyMaxBeats)
+		if s.count == 0 || s.count > o.maxSolutions || s.fewestMoves == 0 || s.bestBeats > o.maxBeats {
+			continue
+		}
+		l.parMoves, l.parBeats = s.fewestMoves, s.bestBeats
+		text = append(text, fmt.Sprintf("\n@par %d %d", l.parMoves, l.parBeats)...)
+		return text, l, true
+	}
+	return nil, l, false
+}
+
+// Generate some levels and write them in a directory,
+// the same seed always gives the same levels
+func generateLevels(dir string, numLevels int, seed int64, o generatorOptions) error {
+	if o.sequenceLen < 1 || o.sequenceLen > 8 || o.width < 3 || o.height < 3 {
+		return fmt.Errorf("invalid generator options")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	for num := 0; num < numLevels; num++ {
+		name := fmt.Sprintf("gen%d-%d", seed, num+1)
+		text, l, ok := o.generateLevel(rng, name)
+		if !ok {
+			return fmt.Errorf("no level found for %s after %d tries", name, generatorMaxTries)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), text, 0644); err != nil {
+			return err
+		}
+		log.Printf("Level %s: par %d moves, %d beats", name, l.parMoves, l.parBeats)
+	}
+
+	return nil
+}
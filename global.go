@@ -26,8 +26,14 @@ const (
 	globalButtonHeight      = 120
 	globalSmallButtonWidth  = 28
 	globalSmallButtonHeight = 31
+	globalActionWidth       = 60
+	globalActionHeight      = 36
+	globalActionRowHeight   = 48
+	globalWorldMargin       = 40
 
 	globalDefaultBPM = 80
 	globalMinBPM     = 20
 	globalMaxBPM     = 150
+
+	globalVersion = 1
 )
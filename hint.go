@@ -0,0 +1,118 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import "log"
+
+// Hints reveal a solution of the current level one slot
+// at a time: first the moves, then the actions of the
+// second track. The solution is the one given in the
+// level metadata if any, else it is searched for (only
+// when the first hint is asked, as this takes some time).
+// The search only tries loops of moves, so levels with a
+// second track need a solution in their metadata to get
+// hints. It runs in the background, result gives the moves
+// found (nil if none) and is nil once the search is over,
+// and asked tells that a hint is waiting for it.
+type hints struct {
+	searched bool
+	found    bool
+	moves    []int
+	actions  []int
+	shown    int
+	result   chan []int
+	asked    bool
+}
+
+// Start looking for the solution used to give hints for
+// a level
+func (h *hints) search(l level, withReset bool) {
+	h.searched = true
+	if len(l.solution) > 0 {
+		if len(l.solution) == l.sequenceLen && len(l.solutionActions) == l.actionLen {
+			h.moves, h.actions, h.found = l.solution, l.solutionActions, true
+			return
+		}
+		log.Printf("Level %s: solution of %d moves and %d actions, should be %d and %d",
+			l.name, len(l.solution), len(l.solutionActions), l.sequenceLen, l.actionLen)
+	}
+	if l.actionLen > 0 {
+		return
+	}
+	maxBeats := difficultyMaxBeats
+	if l.parBeats > 0 {
+		maxBeats = l.parBeats
+	}
+	h.result = make(chan []int, 1)
+	go func(result chan<- []int) {
+		s := solveLevel(l, getLoopChoices(withReset), maxBeats)
+		if s.count == 0 {
+			result <- nil
+			return
+		}
+		result <- s.best
+	}(h.result)
+}
+
+// Tell if the search is still running, without waiting
+// for it, getting its result if it just ended
+func (h *hints) searching() bool {
+	if h.result == nil {
+		return false
+	}
+	select {
+	case moves := <-h.result:
+		h.result = nil
+		h.moves, h.found = moves, moves != nil
+		return false
+	default:
+		return true
+	}
+}
+
+// Tell if it is known that no hint can be given
+func (h hints) unavailable() bool {
+	return h.searched && h.result == nil && !h.found
+}
+
+// Get the number of hints that can be shown
+func (h hints) total() int {
+	return len(h.moves) + len(h.actions)
+}
+
+// Show one more hint, returns false if there is no
+// solution or if all the hints are already shown
+func (h *hints) next() (ok bool) {
+	if !h.found || h.shown >= h.total() {
+		return false
+	}
+	h.shown++
+	return true
+}
+
+// Put the moves and actions revealed by the hints
+// shown so far in some sequences
+func (h hints) apply(moves, actions []int) {
+	for hint := 0; hint < h.shown; hint++ {
+		if hint < len(h.moves) {
+			moves[hint] = h.moves[hint]
+		} else {
+			actions[hint-len(h.moves)] = h.actions[hint-len(h.moves)]
+		}
+	}
+}
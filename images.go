@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -21,6 +23,7 @@ import (
 	"bytes"
 	_ "embed"
 	"image"
+	"image/color"
 	_ "image/png"
 	"log"
 
@@ -51,8 +54,14 @@ var subtitleImage *ebiten.Image
 var smallbuttonsBytes []byte
 var smallbuttonsImage *ebiten.Image
 
+// plain image used for drawing shapes
+var whiteImage *ebiten.Image
+
 // load all images
 func loadImages() {
+	whiteImage = ebiten.NewImage(3, 3)
+	whiteImage.Fill(color.White)
+
 	decoded, _, err := image.Decode(bytes.NewReader(tilesBytes))
 	if err != nil {
 		log.Fatal(err)
@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -18,6 +20,8 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
+	"math/rand/v2"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
@@ -98,3 +102,49 @@ func (l intro) draw(screen *ebiten.Image) {
 		drawTextAt("Click for faster text", 480, float64(globalScreenHeight-40), screen)
 	}
 }
+
+// The scene telling the story at the start of the
+// campaign, or at its end
+type introScene struct {
+	end bool
+}
+
+// Get the story told by the scene
+func (s *introScene) story(g *game) *intro {
+	if s.end {
+		return &g.end
+	}
+	return &g.intro
+}
+
+func (s *introScene) enter(g *game) {}
+
+func (s *introScene) exit(g *game) {}
+
+// Start the first level once the intro is read, or go back
+// to the title screen once the end is read
+func (s *introScene) update(g *game) {
+	if !s.story(g).update() {
+		return
+	}
+	if s.end {
+		g.reset()
+	} else {
+		g.setLevel()
+	}
+	g.soundEngine.nextSounds[soundGo] = true
+}
+
+func (s *introScene) onBeat(g *game) {
+	if s.story(g).updateOnBeat() {
+		g.soundEngine.nextSounds[rand.IntN(3)+soundBlip2] = true
+	}
+}
+
+func (s *introScene) onHalfBeat(g *game) {
+	s.onBeat(g)
+}
+
+func (s *introScene) draw(g *game, screen *ebiten.Image) {
+	s.story(g).draw(screen)
+}
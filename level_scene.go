@@ -0,0 +1,190 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// The scene of a level being played, in one of its phases:
+// setting up the loop, recording it on the beat or playing it
+type levelScene struct {
+	newBeat  bool
+	halfBeat bool
+}
+
+func (s *levelScene) enter(g *game) {}
+
+func (s *levelScene) exit(g *game) {}
+
+func (s *levelScene) onBeat(g *game) {
+	s.newBeat = true
+	g.buttonSet.setBeat()
+	g.character.setBeat()
+}
+
+func (s *levelScene) onHalfBeat(g *game) {
+	s.halfBeat = true
+	g.buttonSet.setHalfBeat()
+	g.character.setHalfBeat()
+}
+
+func (s *levelScene) draw(g *game, screen *ebiten.Image) {
+	g.drawLevel(screen)
+}
+
+// Handle the buttons and keys of the level and, when the
+// loop is played, run it on the beats of the music
+func (s *levelScene) update(g *game) {
+	newBeat, halfBeat := s.newBeat, s.halfBeat
+	s.newBeat, s.halfBeat = false, false
+
+	g.updateAttempt()
+
+	if g.timeAttack.active {
+		g.timeAttack.update()
+	}
+
+	// Pause the level, or skip the current level of
+	// the endless mode
+	if !g.replaying && inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.pause()
+		g.soundEngine.nextSounds[soundBlip2] = true
+		return
+	}
+	if g.endless.active && inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		g.skipEndlessLevel()
+		g.soundEngine.nextSounds[soundGo] = true
+		return
+	}
+
+	g.camera.update(g.character, g.currentLevelNum(), g.phase == phaseSetupSequence, g.cursor.x, g.cursor.y)
+
+	clicked, buttonKind, positionInSequence, smallPosition, action :=
+		g.buttonSet.update(g.cursor.x, g.cursor.y, g.character.moveSequence,
+			g.phase == phaseSetupSequence, g.withReset(), g.withControls())
+
+	if clicked && (buttonKind == buttonIncBPM || buttonKind == buttonDecBPM) {
+		g.attempt.BPMChanges++
+	}
+
+	if clicked && buttonKind == buttonIncBPM {
+		g.bpm += 5
+		if g.bpm > globalMaxBPM {
+			g.bpm = globalMaxBPM
+		} else {
+			g.sequencer.setBpm(g.bpm)
+		}
+	}
+
+	if clicked && buttonKind == buttonDecBPM {
+		g.bpm -= 5
+		if g.bpm < globalMinBPM {
+			g.bpm = globalMinBPM
+		} else {
+			g.sequencer.setBpm(g.bpm)
+		}
+	}
+
+	if clicked && buttonKind == buttonToggleSound {
+		g.soundEngine.toggleSound()
+	}
+
+	// Touching a hazard forces a reset
+	caught := g.phase == phasePlaySequence && g.character.caught
+
+	if g.replaying && ((clicked && buttonKind == buttonReset) || caught ||
+		(newBeat && g.character.checkGoal())) {
+		g.endReplay()
+		return
+	}
+
+	if (clicked && buttonKind == buttonReset) || caught {
+		if caught {
+			g.attempt.Caught++
+		} else {
+			g.attempt.Resets++
+		}
+		g.character.restoreMoves()
+		g.character.reset(g.currentLevel(), g.phase != phasePlaySequence)
+		g.phase = phaseSetupSequence
+		g.soundEngine.nextSounds[soundBack] = true
+		g.resetEffects()
+	} else {
+
+		// Setup a sequence
+		if g.phase == phaseSetupSequence {
+			if g.hints.asked && !g.hints.searching() {
+				g.showHint()
+			}
+			if clicked && buttonKind == buttonPlay {
+				g.playSequence()
+			} else if clicked && buttonKind == buttonSelectMove {
+				g.character.moveSequence[positionInSequence] =
+					getMoveFromChoice(smallPosition, g.character.moveSequence[positionInSequence],
+						len(g.character.moveSequence), g.withReset(), g.withControls())
+			} else if clicked && buttonKind == buttonHint {
+				g.showHint()
+			} else if clicked && buttonKind == buttonAction {
+				g.character.actionSequence[positionInSequence] =
+					(g.character.actionSequence[positionInSequence] + 1) % numActions
+			} else if action.kind != dropNone {
+				g.character.editSequence(action)
+			} else if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+				g.startRecording()
+			}
+		} else if g.phase == phaseRecordSequence {
+			g.updateRecording(newBeat)
+		} else if g.phase == phasePlaySequence {
+			// Run a sequence
+
+			g.updateEffects()
+
+			if newBeat && g.character.checkGoal() {
+				if !g.endless.active {
+					g.recordSolution()
+				}
+				g.endAttempt(outcomeSolved)
+				g.resetEffects()
+				g.soundEngine.nextSounds[soundSuccess] = true
+				g.showResult()
+				return
+			}
+
+			if newBeat {
+				playSound, soundID := g.character.updateOnBeat()
+				if playSound {
+					g.soundEngine.nextSounds[soundID] = true
+				}
+			}
+
+			if halfBeat {
+				playSound, soundID, events := g.character.updateOnHalfBeat()
+				if playSound {
+					g.soundEngine.nextSounds[soundID] = true
+				}
+				g.setUpEffects(events)
+			}
+
+		}
+
+	}
+}
@@ -18,25 +18,49 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
+	"bytes"
 	_ "embed"
-	"image"
-
-	"github.com/hajimehoshi/ebiten/v2"
+	"fmt"
+	"log"
+	"strings"
 )
 
 var levelSet []level
 var levelSteps [3]int
 var levelStepReset int
-
-// A level is an area (a matrix of things such
-// as floor, walls, etc), the number of moves
-// in the loop for the character, a starting
-// position and a goal position.
+var levelStepControls int
+
+// A level is a name (the one of its file), an area
+// (a matrix of things such as floor, walls, etc),
+// objects on top of the area (such as crates), the
+// number of moves in the loop for the characters,
+// their starting positions and the goal positions,
+// the hazards patrolling the level, the number of
+// optional chips to collect, and the number of
+// actions in the second track (0 when there is
+// none). It also has par values: the number of
+// moves (other than nothing) and the number of
+// beats needed by the best known solutions. A par
+// of 0 means no par. A solution (moves and
+// actions) can be given for hints.
 type level struct {
-	area           [][]int
-	sequenceLen    int // The sequence length should never be over 8
-	startX, startY int
-	goalX, goalY   int
+	name               string
+	area               [][]int
+	objects            [][]int
+	sequenceLen        int // The sequence length should never be over 8
+	starts             []position
+	goals              []position
+	hazards            []hazard
+	numChips           int
+	actionLen          int
+	parMoves, parBeats int
+	solution           []int
+	solutionActions    []int
+}
+
+// A position in the area of a level.
+type position struct {
+	x, y int
 }
 
 // The type of things that can be found in a level.
@@ -56,8 +80,36 @@ const (
 	levelReset
 	levelWall
 	levelEmpty
+	levelTeleportRound
+	levelTeleportSquare
+	levelTeleportCurly
+	levelKeyA
+	levelKeyB
+	levelDoorA
+	levelDoorB
+	levelSwitchHold
+	levelSwitchToggle
+	levelSwitchWallUp
+	levelSwitchWallDown
+	levelCrumble
+	levelOneWayUp
+	levelOneWayRight
+	levelOneWayDown
+	levelOneWayLeft
+	levelChip
+)
+
+// The type of objects that can be found on top of
+// the area of a level.
+const (
+	objectNone int = iota
+	objectCrate
 )
 
+// Number of things that have an image in tiles.png
+// before the images of the character and of the goal
+const numTileImagesBeforeCharacter = levelEmpty + 1
+
 //go:embed levels/learn
 var learnLevelBytes []byte
 
@@ -118,51 +170,121 @@ var reset3LevelBytes []byte
 //go:embed levels/block5
 var block5LevelBytes []byte
 
+//go:embed levels/teleport1
+var teleport1LevelBytes []byte
+
+//go:embed levels/keys1
+var keys1LevelBytes []byte
+
+//go:embed levels/switch1
+var switch1LevelBytes []byte
+
+//go:embed levels/crumble1
+var crumble1LevelBytes []byte
+
+//go:embed levels/oneway1
+var oneway1LevelBytes []byte
+
+//go:embed levels/crates1
+var crates1LevelBytes []byte
+
+//go:embed levels/crates2
+var crates2LevelBytes []byte
+
+//go:embed levels/twins1
+var twins1LevelBytes []byte
+
+//go:embed levels/hazard1
+var hazard1LevelBytes []byte
+
+//go:embed levels/chips1
+var chips1LevelBytes []byte
+
+//go:embed levels/tracks1
+var tracks1LevelBytes []byte
+
+//go:embed levels/big1
+var big1LevelBytes []byte
+
 // Set up the levels
 func initLevels() {
 
 	// First level
-	levelSet = append(levelSet, readLevel(learnLevelBytes))
+	levelSet = append(levelSet, readLevel("learn", learnLevelBytes))
 
-	levelSet = append(levelSet, readLevel(basic1LevelBytes))
-	levelSet = append(levelSet, readLevel(basic3LevelBytes))
-	levelSet = append(levelSet, readLevel(basic2LevelBytes))
-	levelSet = append(levelSet, readLevel(basic4LevelBytes))
+	levelSet = append(levelSet, readLevel("basic1", basic1LevelBytes))
+	levelSet = append(levelSet, readLevel("basic3", basic3LevelBytes))
+	levelSet = append(levelSet, readLevel("basic2", basic2LevelBytes))
+	levelSet = append(levelSet, readLevel("basic4", basic4LevelBytes))
 
 	// From there auto moves can be used
 	levelSteps[0] = len(levelSet)
-	levelSet = append(levelSet, readLevel(learnautomoveLevelBytes))
+	levelSet = append(levelSet, readLevel("learnautomove", learnautomoveLevelBytes))
 
-	levelSet = append(levelSet, readLevel(automove3LevelBytes))
-	//levelSet = append(levelSet, readLevel(automove2LevelBytes))
-	levelSet = append(levelSet, readLevel(automove1LevelBytes))
-	levelSet = append(levelSet, readLevel(automove4LevelBytes)) // maybe a bit difficult?
+	levelSet = append(levelSet, readLevel("automove3", automove3LevelBytes))
+	//levelSet = append(levelSet, readLevel("automove2", automove2LevelBytes))
+	levelSet = append(levelSet, readLevel("automove1", automove1LevelBytes))
+	levelSet = append(levelSet, readLevel("automove4", automove4LevelBytes)) // maybe a bit difficult?
 
 	// From there replace blocks can be used
 	levelSteps[1] = len(levelSet)
-	levelSet = append(levelSet, readLevel(learnblockLevelBytes))
+	levelSet = append(levelSet, readLevel("learnblock", learnblockLevelBytes))
 
-	levelSet = append(levelSet, readLevel(block5LevelBytes))
-	levelSet = append(levelSet, readLevel(block4LevelBytes))
-	levelSet = append(levelSet, readLevel(block1LevelBytes))
-	levelSet = append(levelSet, readLevel(block3LevelBytes)) // need correction not difficult if you get the idea of using an empty move
+	levelSet = append(levelSet, readLevel("block5", block5LevelBytes))
+	levelSet = append(levelSet, readLevel("block4", block4LevelBytes))
+	levelSet = append(levelSet, readLevel("block1", block1LevelBytes))
+	levelSet = append(levelSet, readLevel("block3", block3LevelBytes)) // need correction not difficult if you get the idea of using an empty move
 
 	// From there reset can be used (must be after learning auto moves)
 	levelSteps[2] = len(levelSet)
-	levelSet = append(levelSet, readLevel(learnresetLevelBytes))
-
-	levelSet = append(levelSet, readLevel(reset1LevelBytes))
-	levelSet = append(levelSet, readLevel(reset3LevelBytes))
-	levelSet = append(levelSet, readLevel(reset2LevelBytes))
-	levelSet = append(levelSet, readLevel(automove5LevelBytes)) // quite difficult
-	levelSet = append(levelSet, readLevel(block2LevelBytes))    // probably quite difficult
+	levelSet = append(levelSet, readLevel("learnreset", learnresetLevelBytes))
+
+	levelSet = append(levelSet, readLevel("reset1", reset1LevelBytes))
+	levelSet = append(levelSet, readLevel("reset3", reset3LevelBytes))
+	levelSet = append(levelSet, readLevel("reset2", reset2LevelBytes))
+	levelSet = append(levelSet, readLevel("automove5", automove5LevelBytes)) // quite difficult
+	levelSet = append(levelSet, readLevel("block2", block2LevelBytes))       // probably quite difficult
+
+	// From there each level brings a new mechanic,
+	// and the control slots can be used
+	levelStepControls = len(levelSet)
+	levelSet = append(levelSet, readLevel("teleport1", teleport1LevelBytes))
+	levelSet = append(levelSet, readLevel("keys1", keys1LevelBytes))
+	levelSet = append(levelSet, readLevel("switch1", switch1LevelBytes))
+	levelSet = append(levelSet, readLevel("crumble1", crumble1LevelBytes))
+	levelSet = append(levelSet, readLevel("oneway1", oneway1LevelBytes))
+	levelSet = append(levelSet, readLevel("crates1", crates1LevelBytes))
+	levelSet = append(levelSet, readLevel("crates2", crates2LevelBytes))
+	levelSet = append(levelSet, readLevel("twins1", twins1LevelBytes))
+	levelSet = append(levelSet, readLevel("hazard1", hazard1LevelBytes))
+	levelSet = append(levelSet, readLevel("chips1", chips1LevelBytes))
+	levelSet = append(levelSet, readLevel("tracks1", tracks1LevelBytes))
+	levelSet = append(levelSet, readLevel("big1", big1LevelBytes))
 
 	levelStepReset = len(levelSet)
 
 }
 
-// Read a text file representing a level
-func readLevel(levelBytes []byte) (l level) {
+// Read a text file representing a level. Lines
+// starting with @ are metadata about the level,
+// the other ones describe the level itself. Pits
+// are written p and the space outside the walls x,
+// unknown glyphs are errors and are read as walls.
+func readLevel(name string, levelBytes []byte) (l level) {
+	l.name = name
+
+	var areaLines [][]byte
+	for _, line := range bytes.Split(levelBytes, []byte{'\n'}) {
+		if len(line) > 0 && line[0] == '@' {
+			l.readMetadata(string(line[1:]))
+		} else {
+			areaLines = append(areaLines, line)
+		}
+	}
+	levelBytes = bytes.Join(areaLines, []byte{'\n'})
+
+	var crates [][2]int
+	numHazards := 0
 	x, y := 0, -1
 	for _, b := range levelBytes {
 		switch b {
@@ -171,13 +293,11 @@ func readLevel(levelBytes []byte) (l level) {
 			x++
 		case 's':
 			l.area[y] = append(l.area[y], levelFloor)
-			l.startX = x
-			l.startY = y
+			l.starts = append(l.starts, position{x, y})
 			x++
 		case 'g':
 			l.area[y] = append(l.area[y], levelFloor)
-			l.goalX = x
-			l.goalY = y
+			l.goals = append(l.goals, position{x, y})
 			x++
 		case '#':
 			l.area[y] = append(l.area[y], levelWall)
@@ -221,17 +341,172 @@ func readLevel(levelBytes []byte) (l level) {
 		case 'N':
 			l.area[y] = append(l.area[y], levelNothingBox)
 			x++
-		default:
+		case '(', ')':
+			l.area[y] = append(l.area[y], levelTeleportRound)
+			x++
+		case '[', ']':
+			l.area[y] = append(l.area[y], levelTeleportSquare)
+			x++
+		case '{', '}':
+			l.area[y] = append(l.area[y], levelTeleportCurly)
+			x++
+		case 'k':
+			l.area[y] = append(l.area[y], levelKeyA)
+			x++
+		case 'K':
+			l.area[y] = append(l.area[y], levelDoorA)
+			x++
+		case 'j':
+			l.area[y] = append(l.area[y], levelKeyB)
+			x++
+		case 'J':
+			l.area[y] = append(l.area[y], levelDoorB)
+			x++
+		case 'h':
+			l.area[y] = append(l.area[y], levelSwitchHold)
+			x++
+		case 'o':
+			l.area[y] = append(l.area[y], levelSwitchToggle)
+			x++
+		case '=':
+			l.area[y] = append(l.area[y], levelSwitchWallUp)
+			x++
+		case '_':
+			l.area[y] = append(l.area[y], levelSwitchWallDown)
+			x++
+		case 'c':
+			l.area[y] = append(l.area[y], levelCrumble)
+			x++
+		case '^':
+			l.area[y] = append(l.area[y], levelOneWayUp)
+			x++
+		case '>':
+			l.area[y] = append(l.area[y], levelOneWayRight)
+			x++
+		case 'v':
+			l.area[y] = append(l.area[y], levelOneWayDown)
+			x++
+		case '<':
+			l.area[y] = append(l.area[y], levelOneWayLeft)
+			x++
+		case '!':
+			l.area[y] = append(l.area[y], levelFloor)
+			if numHazards >= len(l.hazards) {
+				l.hazards = append(l.hazards, hazard{})
+			}
+			l.hazards[numHazards].x = x
+			l.hazards[numHazards].y = y
+			numHazards++
+			x++
+		case '*':
+			l.area[y] = append(l.area[y], levelChip)
+			l.numChips++
+			x++
+		case '$':
+			l.area[y] = append(l.area[y], levelFloor)
+			crates = append(crates, [2]int{x, y})
+			x++
+		case 'p', 'x':
 			l.area[y] = append(l.area[y], levelEmpty)
 			x++
+		case '\r':
+		default:
+			log.Printf("Level %s: unknown glyph %q at (%d, %d)", l.name, b, x, y)
+			l.area[y] = append(l.area[y], levelWall)
+			x++
 		}
 	}
 
+	if numHazards < len(l.hazards) {
+		log.Printf("Level %s: %d hazard loops for %d hazards", l.name, len(l.hazards), numHazards)
+		l.hazards = l.hazards[:numHazards]
+	}
+
 	simplifyLevelArea(l.area)
+	l.checkTeleports()
+
+	l.objects = make([][]int, len(l.area))
+	for y := range l.area {
+		l.objects[y] = make([]int, len(l.area[y]))
+	}
+	for _, crate := range crates {
+		l.objects[crate[1]][crate[0]] = objectCrate
+	}
 
 	return l
 }
 
+// Check that teleporters come in pairs
+func (l level) checkTeleports() {
+	for kind := levelTeleportRound; kind <= levelTeleportCurly; kind++ {
+		count := 0
+		for y := range l.area {
+			for x := range l.area[y] {
+				if l.area[y][x] == kind {
+					count++
+				}
+			}
+		}
+		if count != 0 && count != 2 {
+			log.Printf("Level %s: %d teleporters of kind %d, should be 2", l.name, count, kind-levelTeleportRound)
+		}
+	}
+}
+
+// Read one line of metadata of a level,
+// of the form "key values". Hazard loops are
+// given to the hazards (!) in their order in
+// the level. Solutions are given as moves,
+// then "|" and actions if there is a second
+// track.
+func (l *level) readMetadata(line string) {
+	key, values, _ := strings.Cut(line, " ")
+	var err error
+	switch key {
+	case "par":
+		_, err = fmt.Sscan(values, &l.parMoves, &l.parBeats)
+	case "actions":
+		_, err = fmt.Sscan(values, &l.actionLen)
+	case "hazard":
+		var moves []int
+		moves, err = parseMoves(values)
+		l.hazards = append(l.hazards, hazard{moves: moves})
+	case "solution":
+		moves, actions, _ := strings.Cut(values, "|")
+		if l.solution, err = parseMoves(moves); err == nil {
+			l.solutionActions, err = parseActions(actions)
+		}
+	}
+	if err != nil {
+		log.Printf("Level %s, metadata %q: %v", l.name, line, err)
+	}
+}
+
+// Get the number of stars obtained for solving a level
+// using a given number of moves and beats: one for solving
+// it, one for each par reached
+func (l level) getStars(movesUsed, beats int) (stars int) {
+	stars = 1
+	if l.parMoves == 0 || movesUsed <= l.parMoves {
+		stars++
+	}
+	if l.parBeats == 0 || beats <= l.parBeats {
+		stars++
+	}
+	return
+}
+
+// Find the position of a level in the level set
+// from its name
+func findLevel(name string) (levelNum int, found bool) {
+	for levelNum, l := range levelSet {
+		if l.name == name {
+			return levelNum, true
+		}
+	}
+	return 0, false
+}
+
 // Set up a level for better display
 func simplifyLevelArea(area [][]int) {
 
@@ -256,99 +531,3 @@ func simplifyLevelArea(area [][]int) {
 		}
 	}
 }
-
-// Draw an area on screen.
-func drawLevelArea(area [][]int, startX, startY float64, screen *ebiten.Image) {
-
-	// draw floor
-	for y := range area {
-		for x := range area[y] {
-			if area[y][x] != levelWall &&
-				area[y][x] != levelCeiling &&
-				area[y][x] != levelEmpty {
-
-				options := &ebiten.DrawImageOptions{}
-				options.GeoM.Translate(
-					startX+float64(x*globalTileSize)-globalTileMargin,
-					startY+float64(y*globalTileSize)-globalTileMargin)
-
-				subImageX := 0
-
-				if (x+y)%2 == 0 {
-					subImageX = (globalTileSize + 2*globalTileMargin)
-				}
-
-				screen.DrawImage(tilesImage.SubImage(
-					image.Rect(subImageX, 0,
-						subImageX+(globalTileSize+2*globalTileMargin),
-						globalTileSize+2*globalTileMargin)).(*ebiten.Image),
-					options)
-			}
-		}
-	}
-
-	// draw walls
-	for y := range area {
-		for x := range area[y] {
-			if area[y][x] == levelWall {
-
-				options := &ebiten.DrawImageOptions{}
-				options.GeoM.Translate(
-					startX+float64(x*globalTileSize)-globalTileMargin,
-					startY+float64(y*globalTileSize)-globalTileMargin)
-
-				subImageX := (globalTileSize + 2*globalTileMargin) * (levelWall + 2)
-
-				screen.DrawImage(tilesImage.SubImage(
-					image.Rect(subImageX, 0,
-						subImageX+(globalTileSize+2*globalTileMargin),
-						globalTileSize+2*globalTileMargin)).(*ebiten.Image),
-					options)
-			}
-		}
-	}
-
-	// draw other things
-	for y := range area {
-		for x := range area[y] {
-			if area[y][x] != levelFloor && area[y][x] != levelEmpty {
-
-				options := &ebiten.DrawImageOptions{}
-				options.GeoM.Translate(
-					startX+float64(x*globalTileSize)-globalTileMargin,
-					startY+float64(y*globalTileSize)-globalTileMargin)
-
-				subImageX := (globalTileSize + 2*globalTileMargin) * (area[y][x] + 1)
-
-				screen.DrawImage(tilesImage.SubImage(
-					image.Rect(subImageX, 0,
-						subImageX+(globalTileSize+2*globalTileMargin),
-						globalTileSize+2*globalTileMargin)).(*ebiten.Image),
-					options)
-			}
-		}
-	}
-
-}
-
-// Draw a goal on screen.
-func drawGoal(x, y int, startX, startY float64, onBeat bool, screen *ebiten.Image) {
-
-	options := &ebiten.DrawImageOptions{}
-	options.GeoM.Translate(
-		startX+float64(x*globalTileSize)-globalTileMargin,
-		startY+float64(y*globalTileSize)-globalTileMargin)
-
-	increment := 4
-	if !onBeat {
-		increment++
-	}
-	subImageX := (globalTileSize + 2*globalTileMargin) * (levelEmpty + increment)
-
-	screen.DrawImage(tilesImage.SubImage(
-		image.Rect(subImageX, 0,
-			subImageX+(globalTileSize+2*globalTileMargin),
-			globalTileSize+2*globalTileMargin)).(*ebiten.Image),
-		options)
-
-}
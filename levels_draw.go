@@ -0,0 +1,263 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Draw an area on screen.
+func drawLevelArea(area [][]int, startX, startY float64, screen *ebiten.Image) {
+
+	// draw floor
+	for y := range area {
+		for x := range area[y] {
+			if area[y][x] != levelWall &&
+				area[y][x] != levelCeiling &&
+				area[y][x] != levelEmpty {
+
+				options := &ebiten.DrawImageOptions{}
+				options.GeoM.Translate(
+					startX+float64(x*globalTileSize)-globalTileMargin,
+					startY+float64(y*globalTileSize)-globalTileMargin)
+
+				subImageX := 0
+
+				if (x+y)%2 == 0 {
+					subImageX = (globalTileSize + 2*globalTileMargin)
+				}
+
+				screen.DrawImage(tilesImage.SubImage(
+					image.Rect(subImageX, 0,
+						subImageX+(globalTileSize+2*globalTileMargin),
+						globalTileSize+2*globalTileMargin)).(*ebiten.Image),
+					options)
+			}
+		}
+	}
+
+	// draw walls
+	for y := range area {
+		for x := range area[y] {
+			if area[y][x] == levelWall {
+
+				options := &ebiten.DrawImageOptions{}
+				options.GeoM.Translate(
+					startX+float64(x*globalTileSize)-globalTileMargin,
+					startY+float64(y*globalTileSize)-globalTileMargin)
+
+				subImageX := (globalTileSize + 2*globalTileMargin) * (levelWall + 2)
+
+				screen.DrawImage(tilesImage.SubImage(
+					image.Rect(subImageX, 0,
+						subImageX+(globalTileSize+2*globalTileMargin),
+						globalTileSize+2*globalTileMargin)).(*ebiten.Image),
+					options)
+			}
+		}
+	}
+
+	// draw other things
+	for y := range area {
+		for x := range area[y] {
+			if area[y][x] != levelFloor && area[y][x] != levelEmpty {
+
+				options := &ebiten.DrawImageOptions{}
+				options.GeoM.Translate(
+					startX+float64(x*globalTileSize)-globalTileMargin,
+					startY+float64(y*globalTileSize)-globalTileMargin)
+
+				imageNum := getTileImageNum(area[y][x])
+				if !hasTileImage(imageNum) {
+					drawTileShape(area[y][x],
+						startX+float64(x*globalTileSize), startY+float64(y*globalTileSize), screen)
+					continue
+				}
+
+				subImageX := (globalTileSize + 2*globalTileMargin) * imageNum
+
+				screen.DrawImage(tilesImage.SubImage(
+					image.Rect(subImageX, 0,
+						subImageX+(globalTileSize+2*globalTileMargin),
+						globalTileSize+2*globalTileMargin)).(*ebiten.Image),
+					options)
+			}
+		}
+	}
+
+}
+
+// Colors used when drawing things with shapes
+var (
+	keyColors = [2]color.RGBA{
+		{R: 0xe0, G: 0xc0, B: 0x40, A: 255},
+		{R: 0x6e, G: 0x9c, B: 0xb0, A: 255},
+	}
+	wallColor      = color.RGBA{R: 0x54, G: 0x33, B: 0x44, A: 255}
+	crateColor     = color.RGBA{R: 0xa8, G: 0x6e, B: 0x3c, A: 255}
+	floorLineColor = color.RGBA{R: 0xca, G: 0xa0, B: 0x5a, A: 255}
+	hazardColor    = color.RGBA{R: 0xc0, G: 0x3c, B: 0x3c, A: 255}
+	chipColor      = color.RGBA{R: 0x4f, G: 0x8a, B: 0x5b, A: 255}
+)
+
+// Get the position of the image of a thing in tiles.png.
+// The things that come after levelEmpty have their images
+// after the ones of the character and of the goal.
+func getTileImageNum(thing int) int {
+	if thing < numTileImagesBeforeCharacter {
+		return thing + 1
+	}
+	return thing + 1 + 4
+}
+
+// Check if tiles.png has an image at a given position
+func hasTileImage(imageNum int) bool {
+	return (imageNum+1)*(globalTileSize+2*globalTileMargin) <= tilesImage.Bounds().Dx()
+}
+
+// Draw a thing that has no image in tiles.png yet with
+// simple shapes, (x, y) is the top left of its tile.
+func drawTileShape(thing int, x, y float64, screen *ebiten.Image) {
+	centerX := float32(x + globalTileSize/2)
+	centerY := float32(y + globalTileSize/2)
+
+	switch thing {
+	case levelTeleportRound, levelTeleportSquare, levelTeleportCurly:
+		clr := teleportColors[thing-levelTeleportRound]
+		vector.StrokeCircle(screen, centerX, centerY, globalTileSize/2-6, 4, clr, true)
+		vector.StrokeCircle(screen, centerX, centerY, globalTileSize/4-4, 2, clr, true)
+	case levelKeyA, levelKeyB:
+		drawKey(centerX, centerY, keyColors[thing-levelKeyA], screen)
+	case levelDoorA, levelDoorB:
+		clr := keyColors[thing-levelDoorA]
+		vector.DrawFilledRect(screen, float32(x)+4, float32(y)+2, globalTileSize-8, globalTileSize-4, clr, true)
+		vector.DrawFilledCircle(screen, centerX, centerY-4, 5, wallColor, true)
+		vector.DrawFilledRect(screen, centerX-2, centerY-4, 4, 12, wallColor, true)
+	case levelSwitchHold:
+		vector.DrawFilledCircle(screen, centerX, centerY, globalTileSize/4, wallColor, true)
+	case levelSwitchToggle:
+		vector.StrokeCircle(screen, centerX, centerY, globalTileSize/4, 3, wallColor, true)
+		vector.DrawFilledCircle(screen, centerX, centerY, globalTileSize/8, wallColor, true)
+	case levelSwitchWallUp:
+		vector.DrawFilledRect(screen, float32(x)+2, float32(y)+2, globalTileSize-4, globalTileSize-4, wallColor, true)
+		vector.StrokeLine(screen, float32(x)+6, float32(y)+globalTileSize-6,
+			float32(x)+globalTileSize-6, float32(y)+6, 3, floorLineColor, true)
+	case levelSwitchWallDown:
+		vector.StrokeRect(screen, float32(x)+4, float32(y)+4, globalTileSize-8, globalTileSize-8, 2, wallColor, true)
+	case levelCrumble:
+		vector.StrokeLine(screen, float32(x)+8, float32(y)+10, centerX, centerY, 2, wallColor, true)
+		vector.StrokeLine(screen, centerX, centerY, float32(x)+globalTileSize-10, float32(y)+14, 2, wallColor, true)
+		vector.StrokeLine(screen, centerX, centerY, centerX-4, float32(y)+globalTileSize-8, 2, wallColor, true)
+	case levelOneWayUp, levelOneWayRight, levelOneWayDown, levelOneWayLeft:
+		drawArrow(centerX, centerY, thing-levelOneWayUp, screen)
+	case levelChip:
+		for pin := float32(-6); pin <= 6; pin += 6 {
+			vector.StrokeLine(screen, centerX+pin, centerY-12, centerX+pin, centerY+12, 2, wallColor, true)
+			vector.StrokeLine(screen, centerX-12, centerY+pin, centerX+12, centerY+pin, 2, wallColor, true)
+		}
+		vector.DrawFilledRect(screen, centerX-8, centerY-8, 16, 16, chipColor, true)
+		vector.StrokeRect(screen, centerX-8, centerY-8, 16, 16, 2, wallColor, true)
+	}
+}
+
+// Draw an arrow centered at (x, y) pointing in
+// the direction of a move
+func drawArrow(x, y float32, move int, screen *ebiten.Image) {
+	// Arrow pointing up, then rotated
+	points := [3][2]float32{{0, -10}, {10, 6}, {-10, 6}}
+	for pos := range points {
+		for turn := 0; turn < move; turn++ {
+			points[pos][0], points[pos][1] = -points[pos][1], points[pos][0]
+		}
+	}
+	for pos := range points {
+		next := (pos + 1) % len(points)
+		vector.StrokeLine(screen, x+points[pos][0], y+points[pos][1],
+			x+points[next][0], y+points[next][1], 3, wallColor, true)
+	}
+}
+
+// Draw a key centered at (x, y)
+func drawKey(x, y float32, clr color.RGBA, screen *ebiten.Image) {
+	vector.StrokeCircle(screen, x-6, y, 6, 3, clr, true)
+	vector.StrokeLine(screen, x, y, x+12, y, 3, clr, true)
+	vector.StrokeLine(screen, x+8, y, x+8, y+6, 3, clr, true)
+	vector.StrokeLine(screen, x+12, y, x+12, y+6, 3, clr, true)
+}
+
+// Draw the objects on top of an area on screen.
+func drawObjects(objects [][]int, startX, startY float64, screen *ebiten.Image) {
+	for y := range objects {
+		for x := range objects[y] {
+			if objects[y][x] == objectCrate {
+				tileX := float32(startX) + float32(x*globalTileSize)
+				tileY := float32(startY) + float32(y*globalTileSize)
+				vector.DrawFilledRect(screen, tileX+4, tileY+2, globalTileSize-8, globalTileSize-8, crateColor, true)
+				vector.StrokeRect(screen, tileX+4, tileY+2, globalTileSize-8, globalTileSize-8, 3, wallColor, true)
+				vector.StrokeLine(screen, tileX+4, tileY+2, tileX+globalTileSize-4, tileY+globalTileSize-6, 2, wallColor, true)
+				vector.StrokeLine(screen, tileX+globalTileSize-4, tileY+2, tileX+4, tileY+globalTileSize-6, 2, wallColor, true)
+			}
+		}
+	}
+}
+
+// Draw a hazard, (x, y) is the top left of its tile.
+// Its spikes grow on beats.
+func drawHazard(x, y float64, onBeat bool, screen *ebiten.Image) {
+	centerX := float32(x + globalTileSize/2)
+	centerY := float32(y + globalTileSize/2)
+	spike := float32(globalTileSize/2 - 4)
+	if !onBeat {
+		spike -= 3
+	}
+	diagonal := spike * 0.7
+	vector.StrokeLine(screen, centerX-spike, centerY, centerX+spike, centerY, 3, wallColor, true)
+	vector.StrokeLine(screen, centerX, centerY-spike, centerX, centerY+spike, 3, wallColor, true)
+	vector.StrokeLine(screen, centerX-diagonal, centerY-diagonal, centerX+diagonal, centerY+diagonal, 3, wallColor, true)
+	vector.StrokeLine(screen, centerX-diagonal, centerY+diagonal, centerX+diagonal, centerY-diagonal, 3, wallColor, true)
+	vector.DrawFilledCircle(screen, centerX, centerY, globalTileSize/4, hazardColor, true)
+	vector.StrokeCircle(screen, centerX, centerY, globalTileSize/4, 2, wallColor, true)
+}
+
+// Draw a goal on screen.
+func drawGoal(x, y int, startX, startY float64, onBeat bool, screen *ebiten.Image) {
+
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Translate(
+		startX+float64(x*globalTileSize)-globalTileMargin,
+		startY+float64(y*globalTileSize)-globalTileMargin)
+
+	increment := 4
+	if !onBeat {
+		increment++
+	}
+	subImageX := (globalTileSize + 2*globalTileMargin) * (levelEmpty + increment)
+
+	screen.DrawImage(tilesImage.SubImage(
+		image.Rect(subImageX, 0,
+			subImageX+(globalTileSize+2*globalTileMargin),
+			globalTileSize+2*globalTileMargin)).(*ebiten.Image),
+		options)
+
+}
@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -18,20 +20,105 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
 
+	replayFile := flag.String("replay", "", "watch the solution stored in a replay file")
+	replayCode := flag.String("code", "", "watch the solution given by a sharing code")
+	replayDir := flag.String("replaydir", "", "save a replay file in this directory for each solved level")
+	telemetryFile := flag.String("telemetry", "", "record each attempt at a level in this file (JSON Lines)")
+	statsFile := flag.String("stats", "", "print per level statistics from a telemetry file and exit")
+	difficulty := flag.Bool("difficulty", false, "print the estimated difficulty of the levels given as arguments (or of the campaign) and exit")
+	generate := flag.Int("generate", 0, "generate this number of levels and exit")
+	genDir := flag.String("gendir", "generated", "directory where generated levels are written")
+	genSeed := flag.Int64("genseed", 1, "seed of the level generator")
+	genOptions := defaultGeneratorOptions
+	flag.IntVar(&genOptions.width, "genwidth", genOptions.width, "width of generated levels")
+	flag.IntVar(&genOptions.height, "genheight", genOptions.height, "height of generated levels")
+	flag.IntVar(&genOptions.sequenceLen, "genlen", genOptions.sequenceLen, "sequence length of generated levels")
+	genMechanics := flag.String("genmechanics", "automove,boxes", "mechanics of generated levels (automove, boxes, reset)")
+	flag.IntVar(&genOptions.maxSolutions, "gensolutions", genOptions.maxSolutions, "maximum number of solving loops of generated levels")
+	flag.IntVar(&genOptions.maxBeats, "genbeats", genOptions.maxBeats, "maximum number of beats of the best solution of generated levels")
+	flag.Parse()
+
+	if *difficulty {
+		initLevels()
+		levels, firstWithReset := levelSet, levelStepReset
+		if flag.NArg() > 0 {
+			levels, firstWithReset = nil, 0
+			for _, path := range flag.Args() {
+				levelBytes, err := os.ReadFile(path)
+				if err != nil {
+					log.Fatal(err)
+				}
+				levels = append(levels, readLevel(filepath.Base(path), levelBytes))
+			}
+		}
+		if err := printDifficulties(levels, firstWithReset, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *generate > 0 {
+		if err := genOptions.setMechanics(*genMechanics); err != nil {
+			log.Fatal(err)
+		}
+		if err := generateLevels(*genDir, *generate, *genSeed, genOptions); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *statsFile != "" {
+		initLevels()
+		if err := printTelemetryStats(*statsFile, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	g := newGame()
+	g.replayDir = *replayDir
+
+	if *telemetryFile != "" {
+		var err error
+		g.telemetry, err = openTelemetry(*telemetryFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer g.telemetry.close()
+	}
+
+	if *replayFile != "" || *replayCode != "" {
+		var s solution
+		var err error
+		if *replayFile != "" {
+			s, err = readSolutionFile(*replayFile, time.Now())
+		} else {
+			s, err = decodeSolution(*replayCode, time.Now())
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.startReplay(s)
+	}
 
 	ebiten.SetWindowTitle("CUB 2: Origins")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	ebiten.SetCursorMode(ebiten.CursorModeHidden)
 
-	if err := ebiten.RunGame(&g); err != nil {
+	err := ebiten.RunGame(&g)
+	g.endAttempt(outcomeQuit)
+	if err != nil {
 		log.Fatal(err)
 	}
 
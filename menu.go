@@ -0,0 +1,344 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// A menu shown over the paused level: a title and a
+// list of items, one per line, that can be clicked
+type menu struct {
+	title string
+	items []menuItem
+}
+
+// An item of a menu, its action tells what clicking
+// it does (and setting which setting it changes)
+type menuItem struct {
+	text    string
+	action  int
+	setting int
+	hover   bool
+}
+
+// Possible actions of menu items
+const (
+	menuNone int = iota
+	menuResume
+	menuRestart
+	menuLevelSelect
+	menuOptions
+	menuQuit
+	menuSetting
+	menuBack
+)
+
+// Position of menus on screen
+const (
+	menuX          = 240
+	menuY          = 140
+	menuLineHeight = 56
+)
+
+// Color drawn over the paused level
+var menuShadeColor = color.NRGBA{R: 0xca, G: 0xa0, B: 0x5a, A: 0xd8}
+
+// Set up the pause menu, level select is only
+// proposed during the campaign
+func newPauseMenu(withLevelSelect bool) (m menu) {
+	m.title = "Pause"
+	m.items = []menuItem{
+		{text: "Resume", action: menuResume},
+		{text: "Restart level", action: menuRestart},
+	}
+	if withLevelSelect {
+		m.items = append(m.items, menuItem{text: "Level select", action: menuLevelSelect})
+	}
+	m.items = append(m.items,
+		menuItem{text: "Options", action: menuOptions},
+		menuItem{text: "Quit to title", action: menuQuit})
+	return
+}
+
+// Set up the options menu showing the current settings
+func newOptionsMenu(s settings) (m menu) {
+	fullscreen := "off"
+	if s.Fullscreen {
+		fullscreen = "on"
+	}
+	m.title = "Options"
+	m.items = []menuItem{
+		{text: fmt.Sprintf("Volume: %d%%", s.Volume), action: menuSetting, setting: settingVolume},
+		{text: fmt.Sprintf("Start speed: %d", s.DefaultBPM), action: menuSetting, setting: settingDefaultBPM},
+		{text: "Fullscreen: " + fullscreen, action: menuSetting, setting: settingFullscreen},
+		{text: "Record keys: " + recordKeysNames[s.RecordKeys], action: menuSetting, setting: settingRecordKeys},
+		{text: "Back", action: menuBack},
+	}
+	return
+}
+
+// Check which item is hovered, and which is clicked: a left
+// click counts as one step forward, a right click on a
+// setting as one step back
+func (m *menu) update(cursorX, cursorY int) (item menuItem, steps int) {
+	for pos, menuItem := range m.items {
+		y := menuY + (pos+1)*menuLineHeight
+		m.items[pos].hover = cursorX >= menuX && cursorX < menuX+len(menuItem.text)*15 &&
+			cursorY >= y && cursorY < y+36
+		if !m.items[pos].hover {
+			continue
+		}
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			return menuItem, 1
+		}
+		if menuItem.action == menuSetting && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+			return menuItem, -1
+		}
+	}
+	return
+}
+
+// Draw a menu over the level
+func (m menu) draw(screen *ebiten.Image) {
+	drawMenuShade(screen)
+	drawTextAt(m.title, menuX, menuY, screen)
+	for pos, item := range m.items {
+		y := float64(menuY + (pos+1)*menuLineHeight)
+		if item.hover {
+			y += 3
+		}
+		drawTextAt(item.text, menuX+20, y, screen)
+	}
+}
+
+// Shade the level behind a menu
+func drawMenuShade(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, globalScreenWidth, globalScreenHeight, menuShadeColor, false)
+}
+
+// The level select page: the levels of the campaign in a
+// grid, with the stars obtained on them. Only the levels
+// up to the one after the last solved one can be chosen.
+type levelSelect struct {
+	stars       []int
+	numUnlocked int
+	hover       int
+	backHover   bool
+}
+
+// Size of the grid of the level select page
+const (
+	levelSelectColumns = 8
+	levelSelectX       = 80
+	levelSelectY       = 110
+	levelSelectWidth   = 80
+	levelSelectHeight  = 64
+	levelSelectBackY   = 530
+)
+
+// Set up the level select page from the progress of the
+// player and the level currently played
+func newLevelSelect(p progress, currentLevel int) (ls levelSelect) {
+	ls.stars = make([]int, len(levelSet))
+	ls.numUnlocked = currentLevel + 1
+	for levelNum, l := range levelSet {
+		ls.stars[levelNum] = p.Stars[l.name]
+		if ls.stars[levelNum] > 0 {
+			ls.numUnlocked = max(ls.numUnlocked, levelNum+2)
+		}
+	}
+	ls.numUnlocked = min(ls.numUnlocked, len(levelSet))
+	return
+}
+
+// Check which level is hovered and if one is chosen
+// (-1 if none), or if the page is left
+func (ls *levelSelect) update(cursorX, cursorY int) (chosen int, back bool) {
+	ls.hover = -1
+	column := (cursorX - levelSelectX) / levelSelectWidth
+	row := (cursorY - levelSelectY) / levelSelectHeight
+	if cursorX >= levelSelectX && cursorY >= levelSelectY && column < levelSelectColumns {
+		if levelNum := row*levelSelectColumns + column; levelNum < ls.numUnlocked {
+			ls.hover = levelNum
+		}
+	}
+	ls.backHover = cursorX >= menuX && cursorX < menuX+4*15 &&
+		cursorY >= levelSelectBackY && cursorY < levelSelectBackY+36
+
+	chosen = -1
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		chosen = ls.hover
+		back = ls.backHover
+	}
+	back = back || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight)
+	return
+}
+
+// Draw the level select page over the level
+func (ls levelSelect) draw(screen *ebiten.Image) {
+	drawMenuShade(screen)
+	drawTextAt("Level select", menuX, 40, screen)
+	for levelNum, stars := range ls.stars {
+		x := float64(levelSelectX + (levelNum%levelSelectColumns)*levelSelectWidth + levelSelectWidth/2)
+		y := float64(levelSelectY + (levelNum/levelSelectColumns)*levelSelectHeight + 20)
+		if levelNum >= ls.numUnlocked {
+			drawCenteredText("-", x, y, ocpFace, screen)
+			continue
+		}
+		if ls.hover == levelNum {
+			y += 3
+		}
+		drawCenteredText(fmt.Sprint(levelNum+1), x, y, ocpFace, screen)
+		for star := 0; star < 3; star++ {
+			drawStar(float32(x)+float32(star-1)*16, float32(y)+26, 7, star < stars, screen)
+		}
+	}
+	y := float64(levelSelectBackY)
+	if ls.backHover {
+		y += 3
+	}
+	drawTextAt("Back", menuX+20, y, screen)
+}
+
+// The scene of the pause menu, shown over the paused level
+type pauseScene struct {
+	menu menu
+}
+
+// Set up the pause menu, level select is only proposed
+// in the campaign
+func (s *pauseScene) enter(g *game) {
+	s.menu = newPauseMenu(!g.endless.active && !g.daily.active && !g.timeAttack.active)
+}
+
+func (s *pauseScene) exit(g *game) {}
+
+// Do what the clicked item of the pause menu says,
+// Escape resumes the level
+func (s *pauseScene) update(g *game) {
+	item, _ := s.menu.update(g.cursor.x, g.cursor.y)
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		item.action = menuResume
+	}
+
+	switch item.action {
+	case menuResume:
+		g.scenes.pop(g)
+		g.soundEngine.nextSounds[soundGo] = true
+	case menuRestart:
+		g.scenes.pop(g)
+		g.restartLevel()
+		g.soundEngine.nextSounds[soundBack] = true
+	case menuLevelSelect:
+		g.scenes.replace(g, &levelSelectScene{})
+		g.soundEngine.nextSounds[soundBlip2] = true
+	case menuOptions:
+		g.scenes.replace(g, &optionsScene{})
+		g.soundEngine.nextSounds[soundBlip2] = true
+	case menuQuit:
+		g.quitToTitle()
+		g.soundEngine.nextSounds[soundBack] = true
+	}
+}
+
+func (s *pauseScene) onBeat(g *game) {}
+
+func (s *pauseScene) onHalfBeat(g *game) {}
+
+func (s *pauseScene) draw(g *game, screen *ebiten.Image) {
+	s.menu.draw(screen)
+}
+
+// The scene of the options menu, shown over the paused level
+type optionsScene struct {
+	menu menu
+}
+
+func (s *optionsScene) enter(g *game) {
+	s.menu = newOptionsMenu(g.settings)
+}
+
+func (s *optionsScene) exit(g *game) {}
+
+// Change the clicked setting, Escape goes back
+// to the pause menu
+func (s *optionsScene) update(g *game) {
+	item, steps := s.menu.update(g.cursor.x, g.cursor.y)
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		item.action = menuBack
+	}
+
+	switch item.action {
+	case menuSetting:
+		g.changeSetting(item.setting, steps)
+		s.menu = newOptionsMenu(g.settings)
+		g.soundEngine.nextSounds[soundBlip2] = true
+	case menuBack:
+		g.scenes.replace(g, &pauseScene{})
+		g.soundEngine.nextSounds[soundBack] = true
+	}
+}
+
+func (s *optionsScene) onBeat(g *game) {}
+
+func (s *optionsScene) onHalfBeat(g *game) {}
+
+func (s *optionsScene) draw(g *game, screen *ebiten.Image) {
+	s.menu.draw(screen)
+}
+
+// The scene of the level select page, shown over the
+// paused level
+type levelSelectScene struct {
+	levelSelect levelSelect
+}
+
+func (s *levelSelectScene) enter(g *game) {
+	s.levelSelect = newLevelSelect(g.progress, g.level)
+}
+
+func (s *levelSelectScene) exit(g *game) {}
+
+// Go to the chosen level, Escape goes back to
+// the pause menu
+func (s *levelSelectScene) update(g *game) {
+	chosen, back := s.levelSelect.update(g.cursor.x, g.cursor.y)
+	if chosen >= 0 {
+		g.selectLevel(chosen)
+		g.soundEngine.nextSounds[soundGo] = true
+	} else if back || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.scenes.replace(g, &pauseScene{})
+		g.soundEngine.nextSounds[soundBack] = true
+	}
+}
+
+func (s *levelSelectScene) onBeat(g *game) {}
+
+func (s *levelSelectScene) onHalfBeat(g *game) {}
+
+func (s *levelSelectScene) draw(g *game, screen *ebiten.Image) {
+	s.levelSelect.draw(screen)
+}
@@ -0,0 +1,175 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// The progress of the player, saved between sessions:
+// the best number of stars obtained on each level, the
+// levels where all the chips were collected in one run,
+// the best streak in the endless mode, the best score
+// obtained on the daily puzzle of each day, and the best
+// time in the time attack mode (in frames, 0 when it was
+// never completed) with its split times, and the number
+// of hints used on each level.
+type progress struct {
+	Stars            map[string]int  `json:"stars"`
+	AllChips         map[string]bool `json:"allChips"`
+	EndlessBest      int             `json:"endlessBest"`
+	Daily            map[string]int  `json:"daily"`
+	TimeAttackBest   int             `json:"timeAttackBest"`
+	TimeAttackSplits []int           `json:"timeAttackSplits"`
+	Hints            map[string]int  `json:"hints"`
+}
+
+// Get the path of a file where the game saves
+// something between sessions
+func configPath(fileName string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cub2-origins", fileName), nil
+}
+
+// Read a value saved as JSON in a file of the game,
+// leaving it as it is if the file does not exist
+func readConfigFile(fileName string, v any) error {
+	path, err := configPath(fileName)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+// Save a value as JSON in a file of the game
+func writeConfigFile(fileName string, v any) error {
+	path, err := configPath(fileName)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// Load the progress of the player, starting with
+// an empty progress if nothing was saved yet
+func loadProgress() (p progress) {
+	p.Stars = make(map[string]int)
+	p.AllChips = make(map[string]bool)
+	p.Daily = make(map[string]int)
+	p.Hints = make(map[string]int)
+
+	if err := readConfigFile("progress.json", &p); err != nil {
+		log.Print("Cannot load progress: ", err)
+	}
+	if p.Stars == nil {
+		p.Stars = make(map[string]int)
+	}
+	if p.AllChips == nil {
+		p.AllChips = make(map[string]bool)
+	}
+	if p.Daily == nil {
+		p.Daily = make(map[string]int)
+	}
+	if p.Hints == nil {
+		p.Hints = make(map[string]int)
+	}
+	return
+}
+
+// Save the progress of the player
+func (p progress) save() {
+	if err := writeConfigFile("progress.json", p); err != nil {
+		log.Print("Cannot save progress: ", err)
+	}
+}
+
+// Record the stars obtained on a level, returns true
+// if this is better than before
+func (p *progress) setStars(levelName string, stars int) (improved bool) {
+	if stars <= p.Stars[levelName] {
+		return false
+	}
+	p.Stars[levelName] = stars
+	return true
+}
+
+// Record that all the chips of a level were collected,
+// returns true if this is new
+func (p *progress) setAllChips(levelName string) (improved bool) {
+	if p.AllChips[levelName] {
+		return false
+	}
+	p.AllChips[levelName] = true
+	return true
+}
+
+// Record a streak in the endless mode, returns true
+// if this is better than before
+func (p *progress) setEndlessBest(streak int) (improved bool) {
+	if streak <= p.EndlessBest {
+		return false
+	}
+	p.EndlessBest = streak
+	return true
+}
+
+// Record the score obtained on the daily puzzle of a
+// day, returns true if this is better than before
+func (p *progress) setDailyScore(day string, score int) (improved bool) {
+	if best, solved := p.Daily[day]; solved && score <= best {
+		return false
+	}
+	p.Daily[day] = score
+	return true
+}
+
+// Record a completed time attack, returns true if
+// this is better than before
+func (p *progress) setTimeAttackBest(frames int, splits []int) (improved bool) {
+	if p.TimeAttackBest > 0 && frames >= p.TimeAttackBest {
+		return false
+	}
+	p.TimeAttackBest = frames
+	p.TimeAttackSplits = append([]int{}, splits...)
+	return true
+}
+
+// Record that a hint was used on a level
+func (p *progress) addHint(levelName string) {
+	p.Hints[levelName]++
+}
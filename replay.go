@@ -0,0 +1,286 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A solution is a sequence of moves (and of actions
+// for levels with a second track) that solves a given
+// level, together with the bpm at which it was played
+// and the version of the game it was found with. It
+// can be shared as a short code or as a replay file.
+// levelNum is -1 for daily puzzles.
+type solution struct {
+	version  int
+	level    level
+	levelNum int
+	bpm      int
+	moves    []int
+	actions  []int
+}
+
+var codeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Number of bytes of checksum at the end of a code, enough
+// for a mistyped code to almost never give a solution
+const codeChecksumLen = 2
+
+// Names of the moves in replay files
+var moveNames = [...]string{
+	moveUp:            "up",
+	moveRight:         "right",
+	moveDown:          "down",
+	moveLeft:          "left",
+	moveReset:         "reset",
+	nothing:           "nothing",
+	moveSkipIfBlocked: "skip",
+	moveRepeat:        "repeat",
+	moveJump:          "jump1",
+	moveJump + 1:      "jump2",
+	moveJump + 2:      "jump3",
+	moveJump + 3:      "jump4",
+	moveJump + 4:      "jump5",
+	moveJump + 5:      "jump6",
+	moveJump + 6:      "jump7",
+	moveLastJump:      "jump8",
+}
+
+// Names of the actions of the second track in replay files
+var actionNames = [...]string{
+	actionNone:        "nothing",
+	actionSwitchWalls: "walls",
+	actionTurnArrows:  "turn",
+}
+
+// Check that a solution can be played in the current game
+func (s solution) check() error {
+	if s.version != globalVersion {
+		return fmt.Errorf("solution made for version %d of the game, this is version %d", s.version, globalVersion)
+	}
+	if s.level.name == "" {
+		return errors.New("unknown level")
+	}
+	if len(s.moves) != s.level.sequenceLen {
+		return fmt.Errorf("level %s needs %d moves, got %d",
+			s.level.name, s.level.sequenceLen, len(s.moves))
+	}
+	for _, move := range s.moves {
+		if move < moveUp || move > moveLastJump || move-moveJump >= len(s.moves) {
+			return errors.New("invalid move")
+		}
+	}
+	if len(s.actions) != s.level.actionLen {
+		return fmt.Errorf("level %s needs %d actions, got %d",
+			s.level.name, s.level.actionLen, len(s.actions))
+	}
+	for _, action := range s.actions {
+		if action < actionNone || action >= numActions {
+			return errors.New("invalid action")
+		}
+	}
+	if s.bpm < globalMinBPM || s.bpm > globalMaxBPM {
+		return errors.New("invalid bpm")
+	}
+	return nil
+}
+
+// Get the sharing code of a solution. The code is the
+// base32 encoding of the version, the bpm, the number of
+// moves and actions, the moves followed by the actions
+// (two per byte), the level name and a checksum.
+func (s solution) code() string {
+	moves := append(append([]int{}, s.moves...), s.actions...)
+	data := []byte{byte(s.version), byte(s.bpm), byte(len(moves))}
+	for pos := 0; pos < len(moves); pos += 2 {
+		packed := byte(moves[pos]) << 4
+		if pos+1 < len(moves) {
+			packed |= byte(moves[pos+1])
+		}
+		data = append(data, packed)
+	}
+	data = append(data, s.level.name...)
+	data = binary.BigEndian.AppendUint16(data, uint16(crc32.ChecksumIEEE(data)))
+	return codeEncoding.EncodeToString(data)
+}
+
+// Read a solution from a sharing code, daily puzzles
+// are found from the given day (see findDailyLevel)
+func decodeSolution(code string, today time.Time) (s solution, err error) {
+	code = strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	code = strings.ReplaceAll(code, "-", "")
+
+	data, err := codeEncoding.DecodeString(code)
+	if err != nil {
+		return s, errors.New("invalid code")
+	}
+	if len(data) < 3+codeChecksumLen {
+		return s, errors.New("invalid code")
+	}
+	checksum := binary.BigEndian.Uint16(data[len(data)-codeChecksumLen:])
+	data = data[:len(data)-codeChecksumLen]
+	if uint16(crc32.ChecksumIEEE(data)) != checksum {
+		return s, errors.New("invalid code")
+	}
+
+	s.version = int(data[0])
+	s.bpm = int(data[1])
+	numMoves := int(data[2])
+	data = data[3:]
+	if len(data) < (numMoves+1)/2 {
+		return s, errors.New("invalid code")
+	}
+	for pos := 0; pos < numMoves; pos++ {
+		packed := data[pos/2]
+		if pos%2 == 0 {
+			packed >>= 4
+		}
+		s.moves = append(s.moves, int(packed&0x0f))
+	}
+	name := string(data[(numMoves+1)/2:])
+
+	var found bool
+	s.level, s.levelNum, found = lookUpLevel(name, today)
+	if !found {
+		return s, fmt.Errorf("unknown level %q", name)
+	}
+	if numActions := s.level.actionLen; numActions > 0 && numActions <= len(s.moves) {
+		s.actions = s.moves[len(s.moves)-numActions:]
+		s.moves = s.moves[:len(s.moves)-numActions]
+	}
+
+	return s, s.check()
+}
+
+// Write a solution to a replay file. The file is made of
+// lines of the form "key value" and also contains the
+// sharing code of the solution.
+func (s solution) writeFile(path string) error {
+	names := make([]string, len(s.moves))
+	for pos, move := range s.moves {
+		names[pos] = moveNames[move]
+	}
+
+	content := fmt.Sprintf("# CUB 2: Origins replay\nversion %d\nlevel %s\nbpm %d\nmoves %s\n",
+		s.version, s.level.name, s.bpm, strings.Join(names, " "))
+	if len(s.actions) > 0 {
+		names = make([]string, len(s.actions))
+		for pos, action := range s.actions {
+			names[pos] = actionNames[action]
+		}
+		content += fmt.Sprintf("actions %s\n", strings.Join(names, " "))
+	}
+	content += fmt.Sprintf("code %s\n", s.code())
+
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// Read a solution from a replay file, daily puzzles are
+// found from the given day. If the file contains a code,
+// the other values are ignored.
+func readSolutionFile(path string, today time.Time) (s solution, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return s, err
+	}
+	defer file.Close()
+
+	hasLevel := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "code":
+			return decodeSolution(value, today)
+		case "version":
+			s.version, err = strconv.Atoi(value)
+		case "bpm":
+			s.bpm, err = strconv.Atoi(value)
+		case "level":
+			s.level, s.levelNum, hasLevel = lookUpLevel(value, today)
+			if !hasLevel {
+				err = fmt.Errorf("unknown level %q", value)
+			}
+		case "moves":
+			s.moves, err = parseMoves(value)
+		case "actions":
+			s.actions, err = parseActions(value)
+		}
+		if err != nil {
+			return s, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return s, err
+	}
+	if !hasLevel {
+		return s, fmt.Errorf("%s: no level given", path)
+	}
+
+	return s, s.check()
+}
+
+// Find a level from its name, in the campaign or among
+// the recent daily puzzles (levelNum is then -1)
+func lookUpLevel(name string, today time.Time) (l level, levelNum int, found bool) {
+	if levelNum, found = findLevel(name); found {
+		return levelSet[levelNum], levelNum, true
+	}
+	d, found := findDailyLevel(name, today)
+	return d.level, -1, found
+}
+
+// Read a list of moves given by their names
+func parseMoves(names string) (moves []int, err error) {
+	return parseNames(names, moveNames[:], "move")
+}
+
+// Read a list of actions given by their names
+func parseActions(names string) (actions []int, err error) {
+	return parseNames(names, actionNames[:], "action")
+}
+
+// Read a list of names, getting the position of
+// each name in a list of known names
+func parseNames(names string, known []string, kind string) (values []int, err error) {
+	for _, name := range strings.Fields(names) {
+		found := false
+		for value, knownName := range known {
+			if name == knownName {
+				values = append(values, value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown %s %q", kind, name)
+		}
+	}
+	return
+}
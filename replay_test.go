@@ -0,0 +1,98 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Get a solution for a level, with moves and actions
+// that do not need to solve it
+func testSolution(levelNum int) (s solution) {
+	s = solution{version: globalVersion, level: levelSet[levelNum], levelNum: levelNum, bpm: 90}
+	for pos := 0; pos < s.level.sequenceLen; pos++ {
+		s.moves = append(s.moves, []int{moveRight, nothing, moveJump}[pos%3])
+	}
+	for pos := 0; pos < s.level.actionLen; pos++ {
+		s.actions = append(s.actions, []int{actionSwitchWalls, actionNone}[pos%2])
+	}
+	return
+}
+
+// Sharing codes give back their solution, and changing
+// any character of a code makes it invalid.
+func TestSolutionCode(t *testing.T) {
+	defer func(saved []level) { levelSet = saved }(levelSet)
+	levelSet = []level{readLevel("basic1", basic1LevelBytes), readLevel("tracks1", tracks1LevelBytes)}
+
+	for levelNum := range levelSet {
+		s := testSolution(levelNum)
+		code := s.code()
+		decoded, err := decodeSolution(code, time.Now())
+		if err != nil {
+			t.Fatalf("%s: %v", s.level.name, err)
+		}
+		if !reflect.DeepEqual(decoded, s) {
+			t.Fatalf("%s: got %+v from the code, want %+v", s.level.name, decoded, s)
+		}
+
+		for pos := range code {
+			mistyped := []byte(code)
+			mistyped[pos] = "AB"[(code[pos]-'A'+1)%2]
+			if _, err := decodeSolution(string(mistyped), time.Now()); err == nil {
+				t.Fatalf("%s: mistyped code %s gives a solution", s.level.name, mistyped)
+			}
+		}
+	}
+}
+
+// Replay files give back their solution, with or without
+// its sharing code.
+func TestSolutionFile(t *testing.T) {
+	defer func(saved []level) { levelSet = saved }(levelSet)
+	levelSet = []level{readLevel("basic1", basic1LevelBytes), readLevel("tracks1", tracks1LevelBytes)}
+
+	for levelNum := range levelSet {
+		s := testSolution(levelNum)
+		path := filepath.Join(t.TempDir(), s.level.name+".replay")
+		if err := s.writeFile(path); err != nil {
+			t.Fatal(err)
+		}
+		read, err := readSolutionFile(path, time.Now())
+		if err != nil || !reflect.DeepEqual(read, s) {
+			t.Fatalf("%s: got %+v from the file, want %+v (error %v)", s.level.name, read, s, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "tracks1.replay")
+	content := "version 1\nlevel tracks1\nbpm 90\nmoves right nothing\nactions walls nothing walls\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readSolutionFile(path, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testSolution(1); !reflect.DeepEqual(read, want) {
+		t.Fatalf("got %+v from the file without code, want %+v", read, want)
+	}
+}
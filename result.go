@@ -0,0 +1,298 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// The result of a solved level, shown before
+// going to the next level. In the endless mode
+// the streak is shown instead of the best stars,
+// and for daily puzzles the score. At the end of
+// a time attack, only the times are shown.
+type levelResult struct {
+	heading    string
+	level      level
+	movesUsed  int
+	beats      int
+	loops      int
+	swaps      int
+	blocked    int
+	chips      int
+	hints      int
+	stars      int
+	bestStars  int
+	improved   bool
+	endless    bool
+	streak     int
+	bestStreak int
+	daily      bool
+	score      int
+	bestScore  int
+	code       string
+	timeAttack bool
+	frames     int
+	bestFrames int
+	splits     []int
+	names      []string
+	onBeat     bool
+	options    []resultOption
+}
+
+// An option proposed on the result screen,
+// drawn as a text that can be clicked
+type resultOption struct {
+	text   string
+	x, y   int
+	action int
+	hover  bool
+}
+
+// Possible choices on the result screen
+const (
+	resultNone int = iota
+	resultRetry
+	resultReplay
+	resultNext
+	resultQuit
+)
+
+// Set up the result of a level from the state of
+// the character that just reached the goal
+func newLevelResult(heading string, l level, c character) (r levelResult) {
+	r.heading = heading
+	r.level = l
+	r.movesUsed = c.countMoves()
+	r.beats = c.beats
+	r.loops = c.loops
+	r.swaps = c.swaps
+	r.blocked = c.blocked
+	r.chips = c.chips
+	r.stars = l.getStars(r.movesUsed, r.beats)
+	r.options = []resultOption{
+		{text: "Retry", x: 80, y: 480, action: resultRetry},
+		{text: "Watch replay", x: 300, y: 480, action: resultReplay},
+		{text: "Next", x: 640, y: 480, action: resultNext},
+	}
+	return
+}
+
+// Show the streak of the endless mode, where levels
+// cannot be retried nor replayed
+func (r *levelResult) setEndless(streak, bestStreak int) {
+	r.endless = true
+	r.streak = streak
+	r.bestStreak = bestStreak
+	r.improved = streak > bestStreak
+	r.options = []resultOption{
+		{text: "Quit", x: 80, y: 480, action: resultQuit},
+		{text: "Next", x: 640, y: 480, action: resultNext},
+	}
+}
+
+// Show the score of a daily puzzle, its best score
+// before (if it was solved before), the streak of
+// days it was solved, and the code of the solution
+func (r *levelResult) setDaily(score, bestScore int, solved bool, streak int, code string) {
+	r.daily = true
+	r.score = score
+	r.bestScore = max(score, bestScore)
+	r.improved = !solved || score > bestScore
+	r.streak = streak
+	r.code = code
+	r.options = []resultOption{
+		{text: "Retry", x: 80, y: 480, action: resultRetry},
+		{text: "Watch replay", x: 300, y: 480, action: resultReplay},
+		{text: "Quit", x: 640, y: 480, action: resultQuit},
+	}
+}
+
+// Set up the result of a time attack from its total
+// time, the best time before (0 if none), and the
+// split times of the levels with their names
+func newTimeAttackResult(frames, bestFrames int, improved bool, splits []int, names []string) (r levelResult) {
+	r.timeAttack = true
+	r.frames = frames
+	r.bestFrames = bestFrames
+	r.improved = improved
+	r.splits = splits
+	r.names = names
+	r.options = []resultOption{
+		{text: "Quit", x: 640, y: 480, action: resultQuit},
+	}
+	return
+}
+
+func (r *levelResult) updateOnBeat() {
+	r.onBeat = !r.onBeat
+}
+
+// Check which option is hovered, and which is clicked
+func (r *levelResult) update(cursorX, cursorY int) (choice int) {
+	for pos, option := range r.options {
+		r.options[pos].hover = cursorX >= option.x && cursorX < option.x+len(option.text)*15 &&
+			cursorY >= option.y && cursorY < option.y+36
+		if r.options[pos].hover && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			choice = option.action
+		}
+	}
+	return
+}
+
+func (r levelResult) draw(screen *ebiten.Image) {
+	r.drawOptions(screen)
+	if r.timeAttack {
+		r.drawTimeAttack(screen)
+		return
+	}
+
+	l := r.level
+
+	drawTextAt(r.heading, 20, 20, screen)
+
+	for star := 0; star < 3; star++ {
+		drawStar(300+float32(star)*100, 130, 40, star < r.stars, screen)
+	}
+
+	if r.endless {
+		text := fmt.Sprintf("Streak: %d (best %d)", r.streak, r.bestStreak)
+		if r.improved {
+			text = fmt.Sprintf("New best streak: %d!", r.streak)
+		}
+		drawTextAt(text, 250, 185, screen)
+	} else if r.daily {
+		text := fmt.Sprintf("Score: %d (best %d)", r.score, r.bestScore)
+		if r.improved {
+			text = fmt.Sprintf("New best score: %d!", r.score)
+		}
+		drawTextAt(text, 250, 185, screen)
+	} else if r.improved {
+		drawTextAt("New record!", 320, 185, screen)
+	} else {
+		drawTextAt(fmt.Sprintf("Best: %d/3", r.bestStars), 330, 185, screen)
+	}
+
+	text := fmt.Sprintf("Beats taken:     %d", r.beats)
+	if l.parBeats > 0 {
+		text = fmt.Sprintf("%s (par %d)", text, l.parBeats)
+	}
+	text += fmt.Sprintf("\nLoop iterations: %d", r.loops)
+	text += fmt.Sprintf("\nMoves used:      %d", r.movesUsed)
+	if l.parMoves > 0 {
+		text = fmt.Sprintf("%s (par %d)", text, l.parMoves)
+	}
+	text += fmt.Sprintf("\nBoxes swapped:   %d", r.swaps)
+	text += fmt.Sprintf("\nBlocked moves:   %d", r.blocked)
+	if l.numChips > 0 {
+		text += fmt.Sprintf("\nChips collected: %d/%d", r.chips, l.numChips)
+	}
+	if r.hints > 0 {
+		text += fmt.Sprintf("\nHints used:      %d", r.hints)
+	}
+	if r.daily {
+		text += fmt.Sprintf("\nDaily streak:    %d", r.streak)
+	}
+	drawTextAt(text, 200, 225, screen)
+
+	if r.code != "" {
+		drawTextAt("Code: "+r.code, 20, 540, screen)
+	}
+}
+
+// Draw the options of the result screen
+func (r levelResult) drawOptions(screen *ebiten.Image) {
+	for _, option := range r.options {
+		y := float64(option.y)
+		if option.hover {
+			y += 3
+		} else if r.onBeat {
+			y -= 3
+		}
+		drawTextAt(option.text, float64(option.x), y, screen)
+	}
+}
+
+// Draw the times of a time attack: the total time and
+// the split times of the levels, in three columns
+func (r levelResult) drawTimeAttack(screen *ebiten.Image) {
+	drawTextAt("Time attack completed in "+formatFrames(r.frames), 20, 20, screen)
+
+	text := "New best time!"
+	if !r.improved {
+		text = "Best: " + formatFrames(r.bestFrames)
+	}
+	drawTextAt(text, 20, 60, screen)
+
+	rows := (len(r.splits) + 2) / 3
+	for levelNum, frames := range r.splits {
+		x := 140 + float64(levelNum/rows)*260
+		y := 120 + float64(levelNum%rows)*24
+		drawCenteredText(fmt.Sprintf("%d. %s %s", levelNum+1, r.names[levelNum], formatFrames(frames)),
+			x, y, smallFace, screen)
+	}
+}
+
+// The scene of the result of a level, a jingle is played
+// when entering it unless coming back from a replay
+type resultScene struct {
+	fromReplay bool
+}
+
+func (s *resultScene) enter(g *game) {
+	if !s.fromReplay {
+		g.sequencer.playJingle([]int{soundC3, soundE3, soundG3, soundC4, soundE4, soundG4, soundC5})
+	}
+}
+
+func (s *resultScene) exit(g *game) {}
+
+func (s *resultScene) update(g *game) {
+	switch g.result.update(g.cursor.x, g.cursor.y) {
+	case resultRetry:
+		g.retryLevel()
+		g.soundEngine.nextSounds[soundBack] = true
+	case resultReplay:
+		g.startReplay(g.lastSolution)
+		g.replayFromResult = true
+		g.soundEngine.nextSounds[soundGo] = true
+	case resultNext:
+		g.nextLevel()
+		g.soundEngine.nextSounds[soundGo] = true
+	case resultQuit:
+		g.quitToTitle()
+		g.soundEngine.nextSounds[soundBack] = true
+	}
+}
+
+func (s *resultScene) onBeat(g *game) {
+	g.result.updateOnBeat()
+}
+
+func (s *resultScene) onHalfBeat(g *game) {
+	s.onBeat(g)
+}
+
+func (s *resultScene) draw(g *game, screen *ebiten.Image) {
+	g.result.draw(screen)
+}
@@ -0,0 +1,120 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+// A rhythm recorder lets the player record the loop live:
+// after a count-in, each slot of the sequence gets the
+// move of the key pressed closest to its beat, judged on
+// how far from the beat the key was pressed. Slots without
+// a key press (or with a missed one) hold nothing.
+type rhythmRecorder struct {
+	moves      []int
+	judgements []int
+	beat       int
+	judgement  int
+}
+
+// Number of beats before the first slot is recorded
+const rhythmCountIn = 4
+
+// Possible judgements of a key press
+const (
+	judgementNone int = iota
+	judgementPerfect
+	judgementGood
+	judgementMiss
+)
+
+// Texts shown for the judgements
+var judgementNames = [...]string{
+	judgementNone:    "",
+	judgementPerfect: "Perfect!",
+	judgementGood:    "Good",
+	judgementMiss:    "Miss",
+}
+
+// Start recording a sequence of a given length, the
+// first beat to come is the first beat of the count-in
+func newRhythmRecorder(sequenceLen int) (r rhythmRecorder) {
+	r.moves = make([]int, sequenceLen)
+	for pos := range r.moves {
+		r.moves[pos] = nothing
+	}
+	r.judgements = make([]int, sequenceLen)
+	r.beat = -1
+	return
+}
+
+// Count a new beat
+func (r *rhythmRecorder) setBeat() {
+	r.beat++
+}
+
+// Get the number of beats left in the count-in,
+// 0 once recording started
+func (r rhythmRecorder) countIn() int {
+	return max(0, rhythmCountIn-r.beat)
+}
+
+// Get the slot recorded at the current beat,
+// -1 during the count-in
+func (r rhythmRecorder) currentSlot() int {
+	if r.beat < rhythmCountIn || r.beat-rhythmCountIn >= len(r.moves) {
+		return -1
+	}
+	return r.beat - rhythmCountIn
+}
+
+// Judge a key press from its distance in frames
+// to the closest beat
+func judgeTiming(distance, framesPerBeat int) int {
+	switch {
+	case distance*6 <= framesPerBeat:
+		return judgementPerfect
+	case distance*3 <= framesPerBeat:
+		return judgementGood
+	}
+	return judgementMiss
+}
+
+// Record a key press giving a move, some frames after the
+// start of the current beat. The press goes to the slot of
+// the closest beat, if that slot has no press yet.
+func (r *rhythmRecorder) press(move, framesSinceBeat, framesPerBeat int) {
+	closest, distance := r.beat, framesSinceBeat
+	if framesSinceBeat*2 > framesPerBeat {
+		closest++
+		distance = framesPerBeat - framesSinceBeat
+	}
+	slot := closest - rhythmCountIn
+	if slot < 0 || slot >= len(r.moves) || r.judgements[slot] != judgementNone {
+		return
+	}
+	r.judgement = judgeTiming(distance, framesPerBeat)
+	r.judgements[slot] = r.judgement
+	if r.judgement != judgementMiss {
+		r.moves[slot] = move
+	}
+}
+
+// Tell if no more key press can be recorded, some frames
+// after the start of the current beat
+func (r rhythmRecorder) done(framesSinceBeat, framesPerBeat int) bool {
+	lastBeat := rhythmCountIn + len(r.moves) - 1
+	return r.beat > lastBeat || (r.beat == lastBeat && framesSinceBeat*2 > framesPerBeat)
+}
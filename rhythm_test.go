@@ -0,0 +1,51 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Key presses recorded on the beat go to the slot of the
+// closest beat, judged on their distance to it.
+func TestRhythmRecorder(t *testing.T) {
+	const framesPerBeat = 24
+	r := newRhythmRecorder(3)
+	for beat := 0; beat < rhythmCountIn; beat++ {
+		r.setBeat()
+	}
+	r.press(moveUp, 23, framesPerBeat) // 1 frame early for the first slot
+	r.setBeat()
+	r.press(moveLeft, 2, framesPerBeat)   // same slot, ignored
+	r.press(moveRight, 18, framesPerBeat) // 6 frames early for the second slot
+	r.setBeat()
+	r.setBeat()
+	if r.done(12, framesPerBeat) {
+		t.Fatal("recording done before the window of the last slot")
+	}
+	r.press(moveDown, 11, framesPerBeat) // 11 frames late for the last slot
+	if !r.done(13, framesPerBeat) {
+		t.Fatal("recording not done after the window of the last slot")
+	}
+
+	if !reflect.DeepEqual(r.moves, []int{moveUp, moveRight, nothing}) ||
+		!reflect.DeepEqual(r.judgements, []int{judgementPerfect, judgementGood, judgementMiss}) {
+		t.Fatalf("got moves %v with judgements %v", r.moves, r.judgements)
+	}
+}
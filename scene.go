@@ -0,0 +1,68 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// A scene is one screen of the game: the title, the intro,
+// a level, the result of a level, a menu... It is told when
+// it becomes the current scene (enter) and when it stops
+// being one (exit), it is updated at each frame and on each
+// beat and half beat of the music, and it is drawn.
+type scene interface {
+	enter(g *game)
+	exit(g *game)
+	update(g *game)
+	onBeat(g *game)
+	onHalfBeat(g *game)
+	draw(g *game, screen *ebiten.Image)
+}
+
+// The scene manager holds the stack of scenes of the game
+// (see sceneStack), gives them the beats of the music and
+// draws them.
+type sceneManager struct {
+	sceneStack[*game, scene]
+}
+
+// Update the current scene, with the beats of the music
+func (m *sceneManager) update(g *game) {
+	m.sceneStack.update(g, func() (newBeat, halfBeat bool) {
+		return g.sequencer.update(&g.soundEngine)
+	})
+}
+
+// Draw all the scenes from the bottom of the stack, and
+// fade in the current one after a transition.
+func (m sceneManager) draw(g *game, screen *ebiten.Image) {
+	for _, s := range m.scenes {
+		s.draw(g, screen)
+	}
+	if m.transition > 0 {
+		alpha := uint8(255 * m.transition / sceneTransitionFrames)
+		vector.DrawFilledRect(screen, 0, 0, globalScreenWidth, globalScreenHeight,
+			color.NRGBA{R: 0xca, G: 0xa0, B: 0x5a, A: alpha}, false)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+// What the scene stack needs from a scene: being told when
+// it becomes the current scene (enter) and when it stops
+// being one (exit), and being updated at each frame and on
+// each beat and half beat of the music (see scene).
+type stackedScene[G any] interface {
+	enter(g G)
+	exit(g G)
+	update(g G)
+	onBeat(g G)
+	onHalfBeat(g G)
+}
+
+// A stack of scenes of a game. Only the scene on top of the
+// stack is updated, the ones below it stay frozen (with the
+// music) but are still drawn, so that a menu can be shown
+// over a paused level. Changing the whole stack for a new
+// scene fades it in. The stack does not depend on the
+// game and the scenes it holds, so it can be tested on its
+// own.
+type sceneStack[G any, S stackedScene[G]] struct {
+	scenes     []S
+	transition int
+}
+
+// Number of frames of the fade between scenes
+const sceneTransitionFrames = 15
+
+// Replace all the scenes by a new one, with a transition.
+func (m *sceneStack[G, S]) set(g G, s S) {
+	for len(m.scenes) > 0 {
+		m.pop(g)
+	}
+	m.push(g, s)
+	m.transition = sceneTransitionFrames
+}
+
+// Put a scene over the current one, which is paused.
+func (m *sceneStack[G, S]) push(g G, s S) {
+	m.scenes = append(m.scenes, s)
+	s.enter(g)
+}
+
+// Remove the current scene, going back to the one below.
+func (m *sceneStack[G, S]) pop(g G) {
+	s := m.current()
+	m.scenes = m.scenes[:len(m.scenes)-1]
+	s.exit(g)
+}
+
+// Replace the current scene by a new one, keeping the
+// scenes below it.
+func (m *sceneStack[G, S]) replace(g G, s S) {
+	m.pop(g)
+	m.push(g, s)
+}
+
+// Get the scene on top of the stack.
+func (m sceneStack[G, S]) current() S {
+	return m.scenes[len(m.scenes)-1]
+}
+
+// Update the current scene, the music goes on (giving its
+// beats to the scene) unless the scene is shown over a
+// paused one. music plays one frame of the music and tells
+// if a beat or a half beat just started.
+func (m *sceneStack[G, S]) update(g G, music func() (newBeat, halfBeat bool)) {
+	if m.transition > 0 {
+		m.transition--
+	}
+
+	s := m.current()
+	if len(m.scenes) == 1 {
+		newBeat, halfBeat := music()
+		if newBeat {
+			s.onBeat(g)
+		}
+		if halfBeat {
+			s.onHalfBeat(g)
+		}
+	}
+	s.update(g)
+}
@@ -0,0 +1,106 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// A scene writing down what the scene stack does with it
+// in a list of events
+type testScene struct {
+	name string
+}
+
+func (s *testScene) enter(events *[]string) {
+	*events = append(*events, "enter "+s.name)
+}
+
+func (s *testScene) exit(events *[]string) {
+	*events = append(*events, "exit "+s.name)
+}
+
+func (s *testScene) update(events *[]string) {
+	*events = append(*events, "update "+s.name)
+}
+
+func (s *testScene) onBeat(events *[]string) {
+	*events = append(*events, "beat "+s.name)
+}
+
+func (s *testScene) onHalfBeat(events *[]string) {
+	*events = append(*events, "half beat "+s.name)
+}
+
+// Scenes are entered and exited when the stack of scenes
+// changes, only the current one is updated, and the music
+// only goes on (giving its beats to the scene) when no
+// scene is below it.
+func TestSceneStack(t *testing.T) {
+	var m sceneStack[*[]string, *testScene]
+	var events []string
+	frames := 0
+	music := func() (newBeat, halfBeat bool) {
+		frames++
+		return frames%2 == 1, frames%2 == 0
+	}
+	level := &testScene{name: "level"}
+	pause := &testScene{name: "pause"}
+	options := &testScene{name: "options"}
+	title := &testScene{name: "title"}
+	check := func(want ...string) {
+		t.Helper()
+		if !reflect.DeepEqual(events, want) {
+			t.Fatalf("got events %q, want %q", events, want)
+		}
+		events = nil
+	}
+
+	m.set(&events, level)
+	m.update(&events, music)
+	m.update(&events, music)
+	check("enter level", "beat level", "update level", "half beat level", "update level")
+	if m.transition != sceneTransitionFrames-2 {
+		t.Fatalf("got %d frames of transition left, want %d", m.transition, sceneTransitionFrames-2)
+	}
+
+	m.push(&events, pause)
+	m.update(&events, music)
+	m.update(&events, music)
+	check("enter pause", "update pause", "update pause")
+	if frames != 2 {
+		t.Fatal("the music went on under the pause menu")
+	}
+
+	m.replace(&events, options)
+	m.pop(&events)
+	check("exit pause", "enter options", "exit options")
+	if m.current() != level {
+		t.Fatal("the level is not back after leaving the menus")
+	}
+	m.update(&events, music)
+	check("beat level", "update level")
+
+	m.push(&events, pause)
+	m.set(&events, title)
+	check("enter pause", "exit pause", "exit level", "enter title")
+	if len(m.scenes) != 1 || m.transition != sceneTransitionFrames {
+		t.Fatalf("got %d scenes and %d frames of transition", len(m.scenes), m.transition)
+	}
+}
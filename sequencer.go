@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -66,8 +68,14 @@ type sequencer struct {
 	sequences     []sequence
 	currentBeat   int
 	currentFrame  int
+	jingle        []int
+	jingleStep    int
+	jingleStarted bool
 }
 
+// Number of beats of the sequencer in a bar
+const sequencerBarBeats = 8
+
 // Set the bpm of a given sequencer while keeping the state of
 // the sequence currently playing
 func (s *sequencer) setBpm(bpm int) {
@@ -80,6 +88,12 @@ func (s *sequencer) setBpm(bpm int) {
 	s.framesPerBeat = newFramesPerBeat
 }
 
+// Get the number of frames since the start of the
+// current beat, once the sequencer was updated
+func (s sequencer) framesSinceBeat() int {
+	return s.currentFrame - 1
+}
+
 // Create a new sequencer given a bpm (beats per minute)
 // and a number of beats per sequencer cycle.
 // The actual bpm is an approximation of the requested bpm
@@ -118,6 +132,10 @@ func (s *sequencer) update(soundEngine *soundEngine) (newBeat, halfBeat bool) {
 		s.sequences[sequencePosition].update(timePosition, reset, soundEngine)
 	}
 
+	if s.currentFrame == 0 {
+		s.updateJingle(soundEngine)
+	}
+
 	newBeat = s.currentFrame == 0
 	halfBeat = s.currentFrame == s.framesPerBeat/2
 
@@ -141,3 +159,26 @@ func (s *sequence) update(timePosition float64, hasReset bool, soundEngine *soun
 		s.currentStep++
 	}
 }
+
+// Ask for a jingle (a list of sounds) to be played,
+// one sound per beat, starting at the next bar.
+func (s *sequencer) playJingle(sounds []int) {
+	s.jingle = sounds
+	s.jingleStep = 0
+	s.jingleStarted = false
+}
+
+// Play the next sound of the jingle if any.
+func (s *sequencer) updateJingle(soundEngine *soundEngine) {
+	if s.jingleStep >= len(s.jingle) {
+		return
+	}
+	if !s.jingleStarted {
+		if s.currentBeat%sequencerBarBeats != 0 {
+			return
+		}
+		s.jingleStarted = true
+	}
+	soundEngine.nextSounds[s.jingle[s.jingleStep]] = true
+	s.jingleStep++
+}
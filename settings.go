@@ -0,0 +1,101 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import "log"
+
+// The settings of the player, saved between sessions: the
+// volume of the sounds (in percent), the speed of the music
+// at the start of the game, whether the game is fullscreen,
+// and the keys used to record the loop on the beat.
+type settings struct {
+	Volume     int  `json:"volume"`
+	DefaultBPM int  `json:"defaultBPM"`
+	Fullscreen bool `json:"fullscreen"`
+	RecordKeys int  `json:"recordKeys"`
+}
+
+// Possible keys to record the loop on the beat
+const (
+	recordKeysArrows int = iota
+	recordKeysWASD
+	numRecordKeys
+)
+
+// Names of the keys to record the loop on the beat
+var recordKeysNames = [numRecordKeys]string{
+	recordKeysArrows: "arrows",
+	recordKeysWASD:   "WASD",
+}
+
+// Steps by which the volume and the default speed of the
+// music change in the options
+const (
+	settingsVolumeStep = 10
+	settingsBPMStep    = 5
+)
+
+// Load the settings of the player, starting with the
+// default ones if nothing was saved yet
+func loadSettings() (s settings) {
+	s = settings{Volume: 100, DefaultBPM: globalDefaultBPM}
+	if err := readConfigFile("settings.json", &s); err != nil {
+		log.Print("Cannot load settings: ", err)
+	}
+	s.clamp()
+	return
+}
+
+// Save the settings of the player
+func (s settings) save() {
+	if err := writeConfigFile("settings.json", s); err != nil {
+		log.Print("Cannot save settings: ", err)
+	}
+}
+
+// Keep the settings within their allowed values
+func (s *settings) clamp() {
+	s.Volume = min(max(s.Volume, 0), 100)
+	s.DefaultBPM = min(max(s.DefaultBPM, globalMinBPM), globalMaxBPM)
+	s.RecordKeys = (s.RecordKeys%numRecordKeys + numRecordKeys) % numRecordKeys
+}
+
+// Change one setting by some steps (negative to go back),
+// the volume and default speed stop at their bounds while
+// the other settings cycle through their values
+func (s *settings) change(setting, steps int) {
+	switch setting {
+	case settingVolume:
+		s.Volume += steps * settingsVolumeStep
+	case settingDefaultBPM:
+		s.DefaultBPM += steps * settingsBPMStep
+	case settingFullscreen:
+		s.Fullscreen = !s.Fullscreen
+	case settingRecordKeys:
+		s.RecordKeys += steps
+	}
+	s.clamp()
+}
+
+// Settings that can be changed in the options
+const (
+	settingVolume int = iota
+	settingDefaultBPM
+	settingFullscreen
+	settingRecordKeys
+)
@@ -0,0 +1,34 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import "testing"
+
+// Settings changed in the options stay within their bounds,
+// or cycle through their values.
+func TestSettings(t *testing.T) {
+	s := settings{Volume: 100, DefaultBPM: globalMinBPM + settingsBPMStep}
+	s.change(settingVolume, 1)
+	s.change(settingDefaultBPM, -2)
+	s.change(settingRecordKeys, -1)
+	s.change(settingFullscreen, -1)
+	want := settings{Volume: 100, DefaultBPM: globalMinBPM, Fullscreen: true, RecordKeys: numRecordKeys - 1}
+	if s != want {
+		t.Fatalf("got settings %+v, want %+v", s, want)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+// Play a sequence of moves (and of actions) on a level
+// without display nor sound, in the same order as the
+// game does it on beats and half beats, until the goal
+// is reached, a hazard catches the character, or maxBeats
+// beats have been played. The character is returned in
+// its final state.
+func playLoop(l level, moves, actions []int, maxBeats int) (c character, success bool) {
+	c.reset(l, true)
+	copy(c.moveSequence, moves)
+	copy(c.actionSequence, actions)
+	c.storeMoves()
+
+	if len(c.moveSequence) == 0 {
+		return c, c.checkGoal()
+	}
+
+	for c.beats < maxBeats {
+		if c.checkGoal() {
+			return c, true
+		}
+		c.updateOnBeat()
+		c.updateOnHalfBeat()
+		if c.caught {
+			return c, false
+		}
+	}
+
+	return c, c.checkGoal()
+}
+
+// Play a loop as playLoop does. The number of beats is
+// the number of moves played before reaching the goal.
+func simulate(l level, moves, actions []int, maxBeats int) (beats int, success bool) {
+	c, success := playLoop(l, moves, actions, maxBeats)
+	return c.beats, success
+}
+
+// What is found by trying all the loops of a level: the
+// number of loops tried and of loops reaching the goal,
+// the loop reaching it in the fewest beats, the smallest
+// number of moves (other than nothing) and of box swaps
+// used by a loop reaching it, and whether all the loops
+// reaching it have nothing slots.
+type levelSolutions struct {
+	total        int
+	count        int
+	best         []int
+	bestBeats    int
+	fewestMoves  int
+	fewestSwaps  int
+	needsNothing bool
+}
+
+// Get the moves that can be used in loops, the reset
+// move being only available in some levels
+func getLoopChoices(withReset bool) (choices []int) {
+	choices = []int{moveUp, moveRight, moveDown, moveLeft, nothing}
+	if withReset {
+		choices = append(choices, moveReset)
+	}
+	return
+}
+
+// Get the moves proposed by the small choice buttons of a
+// slot holding a given move: all the other moves (reset and
+// the control slots are only available in some levels), or
+// the other targets when the slot holds a jump
+func getMoveChoices(currentMove, sequenceLen int, withReset, withControls bool) (choices []int) {
+	if currentMove >= moveJump {
+		for jump := moveJump; jump < moveJump+sequenceLen; jump++ {
+			if jump != currentMove {
+				choices = append(choices, jump)
+			}
+		}
+		return
+	}
+	for move := moveUp; move <= moveJump; move++ {
+		if move == currentMove || (move == moveReset && !withReset) || (move > nothing && !withControls) {
+			continue
+		}
+		choices = append(choices, move)
+	}
+	return
+}
+
+// Retrieve the move chosen from the number of a small choice
+// button and the current move in the sequence of moves
+func getMoveFromChoice(choice, currentMove, sequenceLen int, withReset, withControls bool) (newMove int) {
+	return getMoveChoices(currentMove, sequenceLen, withReset, withControls)[choice]
+}
+
+// Try all the loops of a level made of some possible moves
+// (the actions of a second track, if any, are left to
+// nothing). Each loop is played for at most maxBeats beats.
+func solveLevel(l level, choices []int, maxBeats int) (s levelSolutions) {
+	s.total = 1
+	for pos := 0; pos < l.sequenceLen; pos++ {
+		s.total *= len(choices)
+	}
+	s.bestBeats, s.fewestMoves, s.fewestSwaps = -1, -1, -1
+
+	moves := make([]int, l.sequenceLen)
+	actions := make([]int, l.actionLen)
+	for loop := 0; loop < s.total; loop++ {
+		movesUsed := 0
+		for pos, rest := 0, loop; pos < len(moves); pos++ {
+			moves[pos] = choices[rest%len(choices)]
+			rest /= len(choices)
+			if moves[pos] != nothing {
+				movesUsed++
+			}
+		}
+
+		c, success := playLoop(l, moves, actions, maxBeats)
+		if !success {
+			continue
+		}
+		s.count++
+		if s.count == 1 {
+			s.needsNothing = true
+		}
+		if s.bestBeats < 0 || c.beats < s.bestBeats {
+			s.bestBeats = c.beats
+			s.best = append(s.best[:0], moves...)
+		}
+		if s.fewestMoves < 0 || movesUsed < s.fewestMoves {
+			s.fewestMoves = movesUsed
+		}
+		if s.fewestSwaps < 0 || c.swaps < s.fewestSwaps {
+			s.fewestSwaps = c.swaps
+		}
+		if movesUsed == len(moves) {
+			s.needsNothing = false
+		}
+	}
+
+	return
+}
@@ -0,0 +1,623 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Number of beats after which a loop is considered
+// as not reaching the goal
+const testMaxBeats = 300
+
+// Each level in levels/ has a file of the same name in
+// testdata/solutions/ with lines of the form "good moves"
+// (loops that reach the goal) or "bad moves" (loops that
+// never reach it). Levels must be read without errors. In levels with a second track, the
+// actions follow the moves after a "|". Solutions given
+// in the levels for hints must reach the goal, levels with
+// a second track must give one.
+func TestSolutions(t *testing.T) {
+	files, err := os.ReadDir("levels")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		t.Run(name, func(t *testing.T) {
+			levelBytes, err := os.ReadFile(filepath.Join("levels", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var logged bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logged)
+			l := readLevel(name, levelBytes)
+			if logged.Len() > 0 {
+				t.Errorf("errors reading the level: %s", logged.String())
+			}
+
+			if l.actionLen > 0 && len(l.solution) == 0 {
+				t.Error("no solution given for hints in a level with a second track")
+			}
+			if len(l.solution) > 0 {
+				if _, success := simulate(l, l.solution, l.solutionActions, testMaxBeats); !success {
+					t.Errorf("the solution given for hints does not reach the goal in %d beats", testMaxBeats)
+				}
+			}
+
+			solutions, err := os.Open(filepath.Join("testdata", "solutions", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer solutions.Close()
+
+			numLoops := 0
+			scanner := bufio.NewScanner(solutions)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+				kind, names, _ := strings.Cut(line, " ")
+				moveText, actionText, _ := strings.Cut(names, "|")
+				moves, err := parseMoves(moveText)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(moves) != l.sequenceLen {
+					t.Fatalf("%q has %d moves, level needs %d", names, len(moves), l.sequenceLen)
+				}
+				actions, err := parseActions(actionText)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(actions) != l.actionLen {
+					t.Fatalf("%q has %d actions, level needs %d", names, len(actions), l.actionLen)
+				}
+
+				beats, success := simulate(l, moves, actions, testMaxBeats)
+				switch kind {
+				case "good":
+					if !success {
+						t.Errorf("%q does not reach the goal in %d beats", names, testMaxBeats)
+					} else if beats < l.parBeats {
+						t.Errorf("%q takes %d beats, better than par %d", names, beats, l.parBeats)
+					}
+				case "bad":
+					if success {
+						t.Errorf("%q reaches the goal in %d beats", names, beats)
+					}
+				default:
+					t.Fatalf("unknown kind of loop %q", kind)
+				}
+				numLoops++
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if numLoops == 0 {
+				t.Error("no loop to check")
+			}
+		})
+	}
+}
+
+// A reset move sends the sequence back to its first move
+// instead of going on with the next one.
+func TestResetMove(t *testing.T) {
+	var c character
+	c.reset(readLevel("reset", []byte("3\n#####\n#s.g#\n#####")), true)
+	copy(c.moveSequence, []int{moveRight, moveReset, moveLeft})
+
+	c.updateOnBeat()
+	if c.nextMovePosition != 1 {
+		t.Fatalf("next move is %d after a move, want 1", c.nextMovePosition)
+	}
+	c.updateOnBeat()
+	if c.nextMovePosition != 0 {
+		t.Fatalf("next move is %d after a reset move, want 0", c.nextMovePosition)
+	}
+	if c.currentMovePosition != 1 {
+		t.Fatalf("current move is %d after a reset move, want 1", c.currentMovePosition)
+	}
+}
+
+// A reset tile sends the sequence back to its first move
+// on the half beat, after the move leading to it.
+func TestResetTile(t *testing.T) {
+	var c character
+	c.reset(readLevel("reset", []byte("3\n######\n#sb.g#\n######")), true)
+	copy(c.moveSequence, []int{moveRight, moveRight, moveRight})
+
+	c.updateOnBeat()
+	c.updateOnHalfBeat()
+	if c.nextMovePosition != 0 {
+		t.Fatalf("next move is %d on a reset tile, want 0", c.nextMovePosition)
+	}
+}
+
+// Switching a move with a box on the floor puts the move
+// of the sequence on the floor, except for the nothing move
+// that gives a plain floor.
+func TestBoxSwitch(t *testing.T) {
+	var c character
+	c.reset(readLevel("box", []byte("2\n######\n#sUN.#\n######")), true)
+	copy(c.moveSequence, []int{moveRight, nothing})
+
+	c.updateOnBeat()
+	_, _, events := c.updateOnHalfBeat()
+	if len(events) != 1 || events[0].kind != eventBoxSwitch {
+		t.Fatal("no switch on an up box")
+	}
+	if c.moveSequence[0] != moveUp || c.levelArea[1][2] != levelRightBox {
+		t.Fatalf("got move %d and floor %d, want %d and %d",
+			c.moveSequence[0], c.levelArea[1][2], moveUp, levelRightBox)
+	}
+
+	c.actors[0].x = 3
+	c.currentMovePosition = 1
+	c.updateOnHalfBeat()
+	if c.levelArea[1][3] != levelFloor {
+		t.Fatalf("got floor %d after switching a nothing move, want %d", c.levelArea[1][3], levelFloor)
+	}
+	if c.moveSequence[1] != nothing {
+		t.Fatalf("got move %d from a nothing box, want %d", c.moveSequence[1], nothing)
+	}
+}
+
+// A teleporter sends the character to the other
+// teleporter of its pair on the half beat.
+func TestTeleport(t *testing.T) {
+	var c character
+	c.reset(readLevel("teleport", []byte("1\n########\n#s([.])#\n########")), true)
+	copy(c.moveSequence, []int{moveRight})
+
+	c.updateOnBeat()
+	_, _, events := c.updateOnHalfBeat()
+	if len(events) != 1 || events[0].kind != eventTeleport || c.actors[0].x != 6 || c.actors[0].y != 1 {
+		t.Fatalf("got position (%d, %d) after teleport, want (6, 1)", c.actors[0].x, c.actors[0].y)
+	}
+}
+
+// A door can only be crossed with a key of its color,
+// and the key is used to open it.
+func TestKeyDoor(t *testing.T) {
+	var c character
+	c.reset(readLevel("keys", []byte("1\n########\n#sJkK.g#\n########")), true)
+
+	if c.isAccessible(2, 1, moveLeft) {
+		t.Fatal("door accessible without key")
+	}
+
+	c.actors[0].x = 3
+	c.updateOnHalfBeat()
+	if c.keys[0] != 1 || c.levelArea[1][3] != levelFloor {
+		t.Fatalf("got %d keys and tile %d after picking a key, want 1 and %d", c.keys[0], c.levelArea[1][3], levelFloor)
+	}
+	if c.isAccessible(2, 1, moveLeft) {
+		t.Fatal("door accessible with a key of another color")
+	}
+
+	if !c.applyMove(0, moveRight) || c.actors[0].x != 4 {
+		t.Fatal("cannot cross a door with a key")
+	}
+	if c.keys[0] != 0 || c.levelArea[1][4] != levelFloor {
+		t.Fatalf("got %d keys and tile %d after opening a door, want 0 and %d", c.keys[0], c.levelArea[1][4], levelFloor)
+	}
+}
+
+// Switch walls are lowered while the character stands on
+// a hold switch, and each time it steps on a toggle switch.
+func TestSwitchWalls(t *testing.T) {
+	var c character
+	c.reset(readLevel("switches", []byte("1\n########\n#sh.o=_#\n########")), true)
+
+	c.actors[0].x = 2
+	c.updateOnHalfBeat()
+	if c.levelArea[1][5] != levelSwitchWallDown || c.levelArea[1][6] != levelSwitchWallUp {
+		t.Fatal("walls not switched on a hold switch")
+	}
+	c.actors[0].x = 3
+	c.updateOnHalfBeat()
+	if c.levelArea[1][5] != levelSwitchWallUp || c.levelArea[1][6] != levelSwitchWallDown {
+		t.Fatal("walls not switched back after leaving a hold switch")
+	}
+
+	c.actors[0].x = 4
+	c.updateOnHalfBeat()
+	c.updateOnHalfBeat()
+	if c.levelArea[1][5] != levelSwitchWallDown {
+		t.Fatal("walls not switched by a toggle switch, or switched twice")
+	}
+	c.actors[0].x = 3
+	c.updateOnHalfBeat()
+	if c.levelArea[1][5] != levelSwitchWallDown {
+		t.Fatal("walls switched back after leaving a toggle switch")
+	}
+	c.actors[0].x = 4
+	c.updateOnHalfBeat()
+	if c.levelArea[1][5] != levelSwitchWallUp {
+		t.Fatal("walls not switched when stepping again on a toggle switch")
+	}
+}
+
+// A crumbling floor becomes a pit once the character
+// leaves it, and pits cannot be crossed.
+func TestCrumble(t *testing.T) {
+	var c character
+	c.reset(readLevel("crumble", []byte("1\n######\n#sc.g#\n######")), true)
+
+	c.applyMove(0, moveRight)
+	if c.levelArea[1][2] != levelCrumble {
+		t.Fatal("crumbling floor removed while the character stands on it")
+	}
+	c.applyMove(0, moveRight)
+	if c.levelArea[1][2] != levelEmpty {
+		t.Fatal("crumbling floor not removed after the character left it")
+	}
+	if c.applyMove(0, moveLeft) {
+		t.Fatal("character moved into a pit")
+	}
+}
+
+// A one way floor can only be entered when moving
+// in its direction, but can be left in any direction.
+func TestOneWay(t *testing.T) {
+	var c character
+	c.reset(readLevel("oneway", []byte("1\n#####\n#s>.#\n#.<.#\n#####")), true)
+
+	if !c.applyMove(0, moveRight) {
+		t.Fatal("cannot enter a one way floor in its direction")
+	}
+	if !c.applyMove(0, moveRight) {
+		t.Fatal("cannot leave a one way floor")
+	}
+	if c.applyMove(0, moveLeft) {
+		t.Fatal("entered a one way floor against its direction")
+	}
+	c.applyMove(0, moveDown)
+	if !c.applyMove(0, moveLeft) || c.actors[0].x != 2 {
+		t.Fatal("cannot enter a one way floor in its direction")
+	}
+}
+
+// Pits are walls for the character, and a typo in a level
+// file is read as a wall rather than as a pit.
+func TestLevelGlyphs(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+	l := readLevel("glyphs", []byte("1\n#######\n#s.p?.#\n#######"))
+	walled := readLevel("glyphs", []byte("1\n#######\n#s.p#.#\n#######"))
+	if l.area[1][3] != levelEmpty || !reflect.DeepEqual(l.area, walled.area) {
+		t.Fatalf("got tiles %v, want %v", l.area, walled.area)
+	}
+	var c character
+	c.reset(l, true)
+	if !c.applyMove(0, moveRight) || c.applyMove(0, moveRight) {
+		t.Fatal("walked into a pit")
+	}
+}
+
+// The character pushes crates unless something is behind
+// them, and a crate pushed in a pit fills it.
+func TestCrates(t *testing.T) {
+	var c character
+	c.reset(readLevel("crates", []byte("1\n########\n#s$p.$$#\n########")), true)
+
+	if !c.applyMove(0, moveRight) || c.actors[0].x != 2 {
+		t.Fatal("cannot push a crate")
+	}
+	if c.levelArea[1][3] != levelFloor || c.objects[1][3] != objectNone {
+		t.Fatal("crate pushed in a pit does not fill it")
+	}
+	if !c.applyMove(0, moveRight) || !c.applyMove(0, moveRight) || c.actors[0].x != 4 {
+		t.Fatal("cannot walk on a filled pit")
+	}
+	if c.applyMove(0, moveRight) {
+		t.Fatal("pushed a crate against another crate")
+	}
+
+	c.reset(readLevel("crates", []byte("1\n#####\n#s.$#\n#####")), true)
+	c.applyMove(0, moveRight)
+	if c.applyMove(0, moveRight) || c.objects[1][3] != objectCrate {
+		t.Fatal("pushed a crate into a wall")
+	}
+}
+
+// Characters move at the same time: they can follow each
+// other, but cannot share a position or swap positions.
+func TestActors(t *testing.T) {
+	var c character
+	c.reset(readLevel("actors", []byte("1\n#######\n#ss..s#\n#######")), true)
+
+	moved := c.moveActors([]int{moveRight, moveRight, moveRight})
+	if !moved[0] || !moved[1] || moved[2] || c.actors[0].x != 2 || c.actors[1].x != 3 {
+		t.Fatalf("got %v and positions %d, %d, want a train moving and the last one blocked",
+			moved, c.actors[0].x, c.actors[1].x)
+	}
+
+	c.reset(readLevel("actors", []byte("1\n#####\n#s.s#\n#####")), true)
+	moved = c.moveActors([]int{moveRight, moveLeft})
+	if !moved[0] || moved[1] {
+		t.Fatalf("got %v, want only the first character reaching a shared position", moved)
+	}
+	moved = c.moveActors([]int{moveRight, moveLeft})
+	if moved[0] || moved[1] {
+		t.Fatal("characters swapped their positions")
+	}
+}
+
+// Several characters on boxes swap them with the current
+// move in their order.
+func TestActorsBoxSwitch(t *testing.T) {
+	var c character
+	c.reset(readLevel("actors", []byte("1\n######\n#ssRD#\n######")), true)
+	c.moveSequence[0] = moveUp
+	c.actors[0].x, c.actors[1].x = 3, 4
+
+	_, _, events := c.updateOnHalfBeat()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if c.moveSequence[0] != moveDown || c.levelArea[1][3] != levelUpBox || c.levelArea[1][4] != levelRightBox {
+		t.Fatalf("got move %d and floors %d, %d, want %d and %d, %d",
+			c.moveSequence[0], c.levelArea[1][3], c.levelArea[1][4], moveDown, levelUpBox, levelRightBox)
+	}
+}
+
+// Hazards follow their own loop and catch the character
+// when they reach it or cross its way.
+func TestHazards(t *testing.T) {
+	var c character
+	c.reset(readLevel("hazards", []byte("1\n######\n#s.!.#\n######\n@hazard left")), true)
+	copy(c.moveSequence, []int{moveRight})
+
+	c.updateOnBeat()
+	if !c.caught {
+		t.Fatal("character not caught when crossing a hazard")
+	}
+
+	c.reset(readLevel("hazards", []byte("1\n######\n#s.!.#\n######\n@hazard left")), true)
+	c.updateOnBeat()
+	if c.caught || c.hazards[0].x != 2 {
+		t.Fatalf("got hazard at %d, caught %v, want 2 and not caught", c.hazards[0].x, c.caught)
+	}
+	c.updateOnBeat()
+	if !c.caught {
+		t.Fatal("character not caught when a hazard reaches it")
+	}
+}
+
+// Chips are collected on the half beat, and do not
+// change whether the level is solved.
+func TestChips(t *testing.T) {
+	l := readLevel("chips", []byte("1\n#####\n#s*g#\n#####"))
+	if l.numChips != 1 {
+		t.Fatalf("got %d chips in the level, want 1", l.numChips)
+	}
+
+	var c character
+	c.reset(l, true)
+	copy(c.moveSequence, []int{moveRight})
+	c.updateOnBeat()
+	c.updateOnHalfBeat()
+	if c.chips != 1 || c.levelArea[1][2] != levelFloor {
+		t.Fatalf("got %d chips and tile %d, want 1 and %d", c.chips, c.levelArea[1][2], levelFloor)
+	}
+	c.updateOnBeat()
+	if !c.checkGoal() {
+		t.Fatal("goal not reached after collecting a chip")
+	}
+}
+
+// Control slots take a beat each: skip jumps over the
+// next slot after a blocked move, repeat plays the last
+// move again and jump goes to a given slot.
+func TestControlSlots(t *testing.T) {
+	var c character
+	c.reset(readLevel("control", []byte("4\n#####\n#s.##\n#####")), true)
+	copy(c.moveSequence, []int{moveRight, moveRight, moveSkipIfBlocked, moveLeft})
+	for beat := 0; beat < 3; beat++ {
+		c.updateOnBeat()
+	}
+	if c.nextMovePosition != 0 || c.actors[0].x != 2 {
+		t.Fatalf("got next slot %d and position %d after a skip, want 0 and 2", c.nextMovePosition, c.actors[0].x)
+	}
+
+	c.reset(readLevel("control", []byte("2\n#####\n#s..#\n#####")), true)
+	copy(c.moveSequence, []int{moveRight, moveRepeat})
+	c.updateOnBeat()
+	c.updateOnBeat()
+	if c.actors[0].x != 3 {
+		t.Fatalf("got position %d after a repeat, want 3", c.actors[0].x)
+	}
+
+	c.reset(readLevel("control", []byte("3\n######\n#s...#\n######")), true)
+	copy(c.moveSequence, []int{moveRight, moveJump, moveLeft})
+	for beat := 0; beat < 4; beat++ {
+		c.updateOnBeat()
+	}
+	if c.actors[0].x != 3 {
+		t.Fatalf("got position %d after jumps, want 3", c.actors[0].x)
+	}
+}
+
+// Control slots are proposed on their own, whether the
+// reset move is available or not.
+func TestControlSlotChoices(t *testing.T) {
+	if choices := getMoveChoices(moveUp, 4, false, true); slices.Contains(choices, moveReset) || !slices.Contains(choices, moveJump) {
+		t.Fatalf("got choices %v without reset but with control slots", choices)
+	}
+	if choices := getMoveChoices(moveUp, 4, true, false); !slices.Contains(choices, moveReset) || slices.Contains(choices, moveSkipIfBlocked) {
+		t.Fatalf("got choices %v with reset but without control slots", choices)
+	}
+}
+
+// Control slots have names in replay files.
+func TestControlMoveNames(t *testing.T) {
+	moves, err := parseMoves("skip repeat jump1 jump8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{moveSkipIfBlocked, moveRepeat, moveJump, moveLastJump}
+	for pos := range want {
+		if moves[pos] != want[pos] {
+			t.Fatalf("got moves %v, want %v", moves, want)
+		}
+	}
+}
+
+// The second track plays its own loop of actions on
+// the beat, whatever its length.
+func TestActionTrack(t *testing.T) {
+	var c character
+	c.reset(readLevel("tracks", []byte("1\n#####\n#s.r#\n#####\n@actions 2")), true)
+	copy(c.actionSequence, []int{actionTurnArrows, actionSwitchWalls})
+
+	c.updateOnBeat()
+	if c.levelArea[1][3] != levelDown || c.currentActionPosition != 0 {
+		t.Fatalf("got tile %d after turning arrows, want %d", c.levelArea[1][3], levelDown)
+	}
+	c.updateOnBeat()
+	if !c.wallsToggled {
+		t.Fatal("walls not switched by an action")
+	}
+	c.updateOnBeat()
+	if c.nextActionPosition != 1 || c.nextMovePosition != 0 {
+		t.Fatalf("got next action %d and next move %d, want 1 and 0", c.nextActionPosition, c.nextMovePosition)
+	}
+}
+
+// Trying all the loops of a level finds the best one.
+func TestSolveLevel(t *testing.T) {
+	l := readLevel("solve", []byte("2\n#####\n#s..#\n#..g#\n#####"))
+	s := solveLevel(l, []int{moveUp, moveRight, moveDown, moveLeft, nothing}, testMaxBeats)
+	if s.total != 25 || s.count == 0 || s.bestBeats != 3 || s.fewestMoves != 2 {
+		t.Fatalf("got %d/%d solutions, best in %d beats with %d moves, want best in 3 beats with 2 moves",
+			s.count, s.total, s.bestBeats, s.fewestMoves)
+	}
+}
+
+// Generated levels have the solutions asked for, and the
+// same seed gives the same levels.
+func TestGenerator(t *testing.T) {
+	o := defaultGeneratorOptions
+	text, l, ok := o.generateLevel(rand.New(rand.NewSource(1)), "gen")
+	if !ok {
+		t.Fatal("no level generated")
+	}
+
+	s := solveLevel(readLevel("gen", text), o.getChoices(), difficultyMaxBeats)
+	if s.count < 1 || s.count > o.maxSolutions || s.bestBeats != l.parBeats || s.fewestMoves != l.parMoves ||
+		s.bestBeats > o.maxBeats {
+		t.Fatalf("got %d solutions, best in %d beats with %d moves, want at most %d solutions and par %d %d",
+			s.count, s.bestBeats, s.fewestMoves, o.maxSolutions, l.parBeats, l.parMoves)
+	}
+
+	again, _, _ := o.generateLevel(rand.New(rand.NewSource(1)), "gen")
+	if !bytes.Equal(text, again) {
+		t.Fatal("same seed gave different levels")
+	}
+}
+
+// Mechanics of generated levels are given by exact names.
+func TestGeneratorMechanics(t *testing.T) {
+	var o generatorOptions
+	if err := o.setMechanics("boxes,reset"); err != nil || o.autoMove || !o.boxes || !o.reset {
+		t.Fatalf("got options %+v and error %v", o, err)
+	}
+	for _, list := range []string{"noboxes", "automove,box"} {
+		if err := o.setMechanics(list); err == nil {
+			t.Fatalf("mechanics %q accepted", list)
+		}
+	}
+}
+
+// Levels with fewer solving loops are estimated harder,
+// levels without any are reported as unsolvable, and
+// levels with a second track are not estimated.
+func TestEstimateDifficulty(t *testing.T) {
+	easy := estimateDifficulty(readLevel("easy", []byte("2\n#####\n#s..#\n#..g#\n#####")), false)
+	hard := estimateDifficulty(readLevel("hard", []byte("2\n#######\n#s....#\n#.#.#.#\n#....g#\n#######")), false)
+	if easy.score < 0 || hard.score <= easy.score {
+		t.Fatalf("got difficulties %.1f and %.1f, want the second level harder", easy.score, hard.score)
+	}
+
+	none := estimateDifficulty(readLevel("none", []byte("2\n#####\n#s#g#\n#####")), false)
+	if !none.estimated || none.score != -1 || none.solutions.count != 0 || none.solutions.needsNothing {
+		t.Fatalf("got difficulty %.1f with %d solutions, want an unsolvable level", none.score, none.solutions.count)
+	}
+
+	tracks := estimateDifficulty(readLevel("tracks1", tracks1LevelBytes), false)
+	if tracks.estimated {
+		t.Fatalf("got difficulty %.1f for a level with a second track", tracks.score)
+	}
+}
+
+// Hints reveal the solution of the level metadata one slot
+// at a time, or a solution found by trying all the loops
+// in the background.
+func TestHints(t *testing.T) {
+	l := readLevel("hinted", []byte("2\n#####\n#s..#\n#..g#\n#####\n@solution down right"))
+	var h hints
+	h.search(l, false)
+	moves := []int{nothing, nothing}
+	if !h.next() {
+		t.Fatal("no hint")
+	}
+	h.apply(moves, nil)
+	if !reflect.DeepEqual(moves, []int{moveDown, nothing}) {
+		t.Fatalf("got moves %v after one hint", moves)
+	}
+	if !h.next() || h.next() {
+		t.Fatalf("got %d hints shown, want 2", h.shown)
+	}
+
+	h = hints{}
+	l.solution = nil
+	h.search(l, false)
+	for h.searching() {
+		time.Sleep(time.Millisecond)
+	}
+	for h.next() {
+	}
+	h.apply(moves, nil)
+	if _, success := simulate(l, moves, nil, testMaxBeats); !success || h.shown != 2 {
+		t.Fatalf("got %d hints giving %v, which does not solve the level", h.shown, moves)
+	}
+
+	h = hints{}
+	l.actionLen = 2
+	h.search(l, false)
+	if !h.unavailable() || h.next() {
+		t.Fatal("got hints without a solution for a level with a second track")
+	}
+}
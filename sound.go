@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -98,45 +100,26 @@ type soundEngine struct {
 	nextSounds   [numSounds]bool
 	sounds       [numSounds][]byte
 	mute         bool
+	volume       float64
 }
 
-// The list of existing sounds.
-const (
-	soundKick int = iota
-	soundSnare
-	soundHats
-	soundHats2
-	soundC2
-	soundC3
-	soundC4
-	soundC5
-	soundE3
-	soundE4
-	soundG3
-	soundG4
-	soundBass
-	soundBass2
-	soundBlip
-	soundBlip2
-	soundBlip3
-	soundBlip4
-	soundSuccess
-	soundGo
-	soundBack
-	numSounds
-)
-
 // Toggle sound
 func (s *soundEngine) toggleSound() {
 	s.mute = !s.mute
 }
 
+// Set the volume of all sounds, in percent
+func (s *soundEngine) setVolume(percent int) {
+	s.volume = float64(percent) / 100
+}
+
 // Initialisation of the sound engine (sound decoding).
 func newSoundEngine() (engine soundEngine) {
 
 	var err error
 	var sound *wav.Stream
 	engine.audioContext = audio.NewContext(44100)
+	engine.volume = 1
 
 	sound, err = wav.DecodeWithSampleRate(engine.audioContext.SampleRate(), bytes.NewReader(kickBytes))
 	if err != nil {
@@ -345,17 +328,17 @@ func (e soundEngine) playSound(ID int) {
 	if !e.mute {
 		soundPlayer := e.audioContext.NewPlayerFromBytes(e.sounds[ID])
 		volume := 0.7
-		soundPlayer.SetVolume(volume)
 		switch ID {
 		case soundHats2:
-			soundPlayer.SetVolume(volume - 0.2)
+			volume -= 0.2
 		case soundBass, soundBass2, soundC2:
-			soundPlayer.SetVolume(volume - 0.1)
+			volume -= 0.1
 		case soundC3, soundC4, soundC5, soundE3, soundE4, soundG3, soundG4:
-			soundPlayer.SetVolume(volume - 0.25)
+			volume -= 0.25
 		case soundBlip:
-			soundPlayer.SetVolume(volume + 0.2)
+			volume += 0.2
 		}
+		soundPlayer.SetVolume(volume * e.volume)
 		soundPlayer.Play()
 	}
 }
@@ -0,0 +1,44 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+// The list of existing sounds.
+const (
+	soundKick int = iota
+	soundSnare
+	soundHats
+	soundHats2
+	soundC2
+	soundC3
+	soundC4
+	soundC5
+	soundE3
+	soundE4
+	soundG3
+	soundG4
+	soundBass
+	soundBass2
+	soundBlip
+	soundBlip2
+	soundBlip3
+	soundBlip4
+	soundSuccess
+	soundGo
+	soundBack
+	numSounds
+)
@@ -0,0 +1,186 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// An attempt at solving a level, from its set up to its
+// completion (or to the player leaving the game). Attempts
+// are recorded as JSON Lines when telemetry is enabled.
+type attempt struct {
+	Session      string    `json:"session"`
+	Level        string    `json:"level"`
+	Start        time.Time `json:"start"`
+	SetupSeconds float64   `json:"setupSeconds"`
+	TotalSeconds float64   `json:"totalSeconds"`
+	Plays        int       `json:"plays"`
+	Resets       int       `json:"resets"`
+	Restarts     int       `json:"restarts"`
+	Caught       int       `json:"caught"`
+	Hints        int       `json:"hints"`
+	BPMChanges   int       `json:"bpmChanges"`
+	Sequence     []string  `json:"sequence"`
+	Outcome      string    `json:"outcome"`
+	setupFrames  int
+	totalFrames  int
+	inProgress   bool
+}
+
+// Possible outcomes of an attempt
+const (
+	outcomeSolved  = "solved"
+	outcomeQuit    = "quit"
+	outcomeSkipped = "skipped"
+)
+
+// The telemetry log, a file where attempts are appended.
+// Each run of the game is a session.
+type telemetry struct {
+	file    *os.File
+	encoder *json.Encoder
+	session string
+}
+
+// Open (or create) a telemetry log
+func openTelemetry(path string) (t *telemetry, err error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &telemetry{
+		file:    file,
+		encoder: json.NewEncoder(file),
+		session: time.Now().Format("20060102-150405.000"),
+	}, nil
+}
+
+// Append an attempt to the log
+func (t *telemetry) record(a attempt) {
+	if err := t.encoder.Encode(a); err != nil {
+		log.Print("Cannot record telemetry: ", err)
+	}
+}
+
+func (t *telemetry) close() {
+	if err := t.file.Close(); err != nil {
+		log.Print("Cannot close telemetry: ", err)
+	}
+}
+
+// Statistics about one level over all sessions of a
+// telemetry log
+type levelStats struct {
+	sessions map[string]bool
+	solved   map[string]bool
+	times    map[string]float64
+	attempts int
+	plays    int
+	resets   int
+	restarts int
+	hints    int
+}
+
+// Read a telemetry log and write, for each level, how
+// many sessions reached it and solved it, and the median
+// time spent on it by a session
+func printTelemetryStats(path string, w io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stats := make(map[string]*levelStats)
+	var levelNames []string
+	for _, l := range levelSet {
+		levelNames = append(levelNames, l.name)
+	}
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		var a attempt
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		s, found := stats[a.Level]
+		if !found {
+			s = &levelStats{
+				sessions: make(map[string]bool),
+				solved:   make(map[string]bool),
+				times:    make(map[string]float64),
+			}
+			stats[a.Level] = s
+			if _, known := findLevel(a.Level); !known {
+				levelNames = append(levelNames, a.Level)
+			}
+		}
+		s.sessions[a.Session] = true
+		if a.Outcome == outcomeSolved {
+			s.solved[a.Session] = true
+		}
+		s.times[a.Session] += a.TotalSeconds
+		s.attempts++
+		s.plays += a.Plays
+		s.resets += a.Resets
+		s.restarts += a.Restarts
+		s.hints += a.Hints
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%-15s %8s %8s %8s %8s %8s %8s %8s %12s\n",
+		"level", "reached", "solved", "attempts", "plays", "resets", "restarts", "hints", "median time")
+	for _, name := range levelNames {
+		s, found := stats[name]
+		if !found {
+			fmt.Fprintf(w, "%-15s %8d\n", name, 0)
+			continue
+		}
+		var times []float64
+		for _, sessionTime := range s.times {
+			times = append(times, sessionTime)
+		}
+		fmt.Fprintf(w, "%-15s %8d %8d %8d %8d %8d %8d %8d %11.1fs\n",
+			name, len(s.sessions), len(s.solved), s.attempts, s.plays, s.resets, s.restarts, s.hints, median(times))
+	}
+
+	return nil
+}
+
+// Get the median of a list of values
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+	return values[middle]
+}
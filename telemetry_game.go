@@ -0,0 +1,69 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import "time"
+
+// Start recording an attempt at the current level
+func (g *game) startAttempt() {
+	g.attempt = attempt{
+		Level:      g.currentLevel().name,
+		Start:      time.Now(),
+		inProgress: true,
+	}
+}
+
+// Count the time spent in the current attempt
+func (g *game) updateAttempt() {
+	if !g.attempt.inProgress || g.replaying {
+		return
+	}
+	if g.phase == phaseSetupSequence {
+		g.attempt.setupFrames++
+	}
+	g.attempt.totalFrames++
+}
+
+// Finish the current attempt and record it if
+// telemetry is enabled
+func (g *game) endAttempt(outcome string) {
+	if !g.attempt.inProgress {
+		return
+	}
+	g.attempt.inProgress = false
+
+	if g.telemetry == nil {
+		return
+	}
+
+	moves := g.character.originalMoveSequence
+	if g.phase == phaseSetupSequence || g.phase == phaseRecordSequence {
+		moves = g.character.moveSequence
+	}
+	for _, move := range moves {
+		g.attempt.Sequence = append(g.attempt.Sequence, moveNames[move])
+	}
+
+	g.attempt.Session = g.telemetry.session
+	g.attempt.SetupSeconds = float64(g.attempt.setupFrames) / 60
+	g.attempt.TotalSeconds = float64(g.attempt.totalFrames) / 60
+	g.attempt.Outcome = outcome
+	g.telemetry.record(g.attempt)
+}
@@ -0,0 +1,89 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The statistics of a telemetry log give, for each level of
+// the campaign and then for the other levels found in the
+// log, how far sessions went and the median time they
+// spent on it.
+func TestTelemetryStats(t *testing.T) {
+	defer func(saved []level) { levelSet = saved }(levelSet)
+	levelSet = []level{readLevel("basic1", basic1LevelBytes), readLevel("basic2", basic2LevelBytes),
+		readLevel("basic3", basic3LevelBytes)}
+
+	log := strings.Join([]string{
+		`{"session":"a","level":"basic1","totalSeconds":10,"plays":2,"resets":1,"outcome":"quit"}`,
+		`{"session":"a","level":"basic1","totalSeconds":20,"plays":1,"restarts":1,"outcome":"solved"}`,
+		`{"session":"b","level":"basic1","totalSeconds":40,"plays":3,"hints":2,"outcome":"solved"}`,
+		`{"session":"c","level":"basic1","totalSeconds":5,"plays":1,"outcome":"quit"}`,
+		`{"session":"a","level":"basic2","totalSeconds":7,"plays":1,"outcome":"solved"}`,
+		`{"session":"b","level":"endless-1","totalSeconds":3,"outcome":"skipped"}`,
+	}, "\n")
+	path := filepath.Join(t.TempDir(), "telemetry.jsonl")
+	if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := printTelemetryStats(path, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"level", "reached", "solved", "attempts", "plays", "resets", "restarts", "hints", "median", "time"},
+		{"basic1", "3", "2", "4", "7", "1", "1", "2", "30.0s"},
+		{"basic2", "1", "1", "1", "1", "0", "0", "0", "7.0s"},
+		{"basic3", "0"},
+		{"endless-1", "1", "0", "1", "0", "0", "0", "0", "3.0s"},
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines of statistics, want %d:\n%s", len(lines), len(want), out.String())
+	}
+	for lineNum, line := range lines {
+		if fields := strings.Fields(line); !reflect.DeepEqual(fields, want[lineNum]) {
+			t.Errorf("got %q, want %q", fields, want[lineNum])
+		}
+	}
+}
+
+// The median is the middle value, or the mean of the two
+// middle values for an even number of values.
+func TestMedian(t *testing.T) {
+	for _, test := range []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{4}, 4},
+		{[]float64{9, 1, 5}, 5},
+		{[]float64{8, 2, 6, 1}, 4},
+	} {
+		if got := median(test.values); got != test.want {
+			t.Errorf("got median %v of %v, want %v", got, test.values, test.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+//go:build !headless
+
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Colors of the pairs of teleporters
+var teleportColors = [3]color.RGBA{
+	{R: 0x8b, G: 0x40, B: 0x49, A: 255},
+	{R: 0x3b, G: 0x5d, B: 0x6e, A: 255},
+	{R: 0x4f, G: 0x6b, B: 0x3a, A: 255},
+}
+
+// Visual effect when the character is teleported
+type teleportEffect struct {
+	fromX, fromY     float32
+	toX, toY         float32
+	frame, numFrames int
+	clr              color.RGBA
+}
+
+// Check if the effect is over
+func (t teleportEffect) done() bool {
+	return t.frame >= t.numFrames
+}
+
+func (t *teleportEffect) setUp(
+	fromX, fromY, toX, toY int, displayX, displayY float64,
+	bpm int, kind int) {
+
+	t.fromX = float32(displayX) + float32(fromX*globalTileSize) + globalTileSize/2
+	t.fromY = float32(displayY) + float32(fromY*globalTileSize) + globalTileSize/2
+	t.toX = float32(displayX) + float32(toX*globalTileSize) + globalTileSize/2
+	t.toY = float32(displayY) + float32(toY*globalTileSize) + globalTileSize/2
+
+	t.frame = 0
+	t.numFrames = 3600 / (4 * bpm)
+	t.clr = teleportColors[kind-levelTeleportRound]
+}
+
+func (t *teleportEffect) update() {
+	if t.frame < t.numFrames {
+		t.frame++
+	}
+}
+
+// The ring at the origin shrinks while the ring
+// at the destination grows
+func (t teleportEffect) draw(screen *ebiten.Image) {
+	if t.frame < t.numFrames {
+		progress := float32(t.frame) / float32(t.numFrames)
+		radius := float32(globalTileSize) / 2
+		vector.StrokeCircle(screen, t.fromX, t.fromY, radius*(1-progress)+1, 3, t.clr, true)
+		vector.StrokeCircle(screen, t.toX, t.toY, radius*progress+1, 3, t.clr, true)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+
+// The time attack mode: the levels of the campaign played
+// back to back with a single clock, counting frames (60
+// per second). The time taken by each level is kept as a
+// split time, and solving a level quickly makes the music
+// faster for the next one.
+type timeAttack struct {
+	active     bool
+	frames     int
+	levelStart int
+	splits     []int
+}
+
+// Levels solved in less than timeAttackFastFrames raise the
+// speed of the music by timeAttackFastStep, levels solved
+// in less than timeAttackQuickFrames by timeAttackQuickStep
+const (
+	timeAttackFastFrames  = 20 * 60
+	timeAttackFastStep    = 10
+	timeAttackQuickFrames = 40 * 60
+	timeAttackQuickStep   = 5
+)
+
+// Count one frame
+func (t *timeAttack) update() {
+	t.frames++
+}
+
+// Record the split time of the level that was just solved
+func (t *timeAttack) split() (frames int) {
+	frames = t.frames - t.levelStart
+	t.splits = append(t.splits, frames)
+	t.levelStart = t.frames
+	return
+}
+
+// Rule of the time attack: the speed of the music goes up
+// after a level was solved in a small number of frames
+func timeAttackBPM(levelNum, bpm, playerBPM, frames int) int {
+	switch {
+	case frames < timeAttackFastFrames:
+		bpm += timeAttackFastStep
+	case frames < timeAttackQuickFrames:
+		bpm += timeAttackQuickStep
+	}
+	return min(max(bpm, globalMinBPM), globalMaxBPM)
+}
+
+// Get a number of frames as minutes, seconds and
+// hundredths of seconds
+func formatFrames(frames int) string {
+	hundredths := frames * 100 / 60
+	return fmt.Sprintf("%d:%02d.%02d", hundredths/6000, hundredths/100%60, hundredths%100)
+}
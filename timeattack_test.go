@@ -0,0 +1,54 @@
+/*
+CUB 2: Origins, a game for GMTK Game Jam 2025
+Copyright (C) 2025 Loïg Jezequel
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Quickly solved levels of a time attack make the music
+// faster, within the allowed speeds, and each level gets
+// its split time.
+func TestTimeAttack(t *testing.T) {
+	var ta timeAttack
+	for frame := 0; frame < 90; frame++ {
+		ta.update()
+	}
+	ta.split()
+	for frame := 0; frame < 30*60; frame++ {
+		ta.update()
+	}
+	ta.split()
+	if !reflect.DeepEqual(ta.splits, []int{90, 30 * 60}) || formatFrames(ta.frames) != "0:31.50" {
+		t.Fatalf("got splits %v and total %s", ta.splits, formatFrames(ta.frames))
+	}
+
+	if bpm := timeAttackBPM(0, 80, 80, ta.splits[0]); bpm != 80+timeAttackFastStep {
+		t.Fatalf("got %d BPM after a fast level", bpm)
+	}
+	if bpm := timeAttackBPM(0, 80, 80, ta.splits[1]); bpm != 80+timeAttackQuickStep {
+		t.Fatalf("got %d BPM after a quick level", bpm)
+	}
+	if bpm := timeAttackBPM(0, 80, 80, timeAttackQuickFrames); bpm != 80 {
+		t.Fatalf("got %d BPM after a slow level", bpm)
+	}
+	if bpm := timeAttackBPM(0, globalMaxBPM, 80, 0); bpm != globalMaxBPM {
+		t.Fatalf("got %d BPM, above the maximum", bpm)
+	}
+}
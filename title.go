@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -19,6 +21,8 @@ package main
 
 import (
 	"image"
+	"time"
+	"unicode"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -28,6 +32,36 @@ type title struct {
 	onBeat    bool
 	upChar    int
 	upSubChar int
+	codeInput []rune
+	codeError string
+	hover     int
+}
+
+// A text of the title screen that starts a game mode
+// when clicked
+type titleOption struct {
+	text   string
+	x, y   int
+	choice int
+}
+
+// Possible choices on the title screen
+const (
+	titleNone int = iota
+	titleStart
+	titleEndless
+	titleDaily
+	titleTimeAttack
+)
+
+// Maximum length of a typed solution code, so that it
+// fits on one line of the screen
+const titleCodeMaxLen = 80
+
+var titleOptions = []titleOption{
+	{text: "Time attack", x: 580, y: 410, choice: titleTimeAttack},
+	{text: "Daily puzzle", x: 580, y: 470, choice: titleDaily},
+	{text: "Endless mode", x: 580, y: 530, choice: titleEndless},
 }
 
 func (t title) draw(screen *ebiten.Image) {
@@ -69,6 +103,26 @@ func (t title) draw(screen *ebiten.Image) {
 	}
 	drawTextAt("Click to start", 300, float64(y), screen)
 
+	// Solution code, typed codes and errors get the whole
+	// width of the screen under the game modes
+	drawTextAt("Type a solution code to watch it", 20, 530, screen)
+	if len(t.codeInput) > 0 {
+		drawCenteredText("Code: "+string(t.codeInput), globalScreenWidth/2, 585, smallFace, screen)
+	} else if t.codeError != "" {
+		drawCenteredText(t.codeError, globalScreenWidth/2, 585, smallFace, screen)
+	}
+
+	// Game modes
+	for _, option := range titleOptions {
+		y := float64(option.y)
+		if t.hover == option.choice {
+			y += 3
+		} else if t.onBeat {
+			y -= 3
+		}
+		drawTextAt(option.text, float64(option.x), y, screen)
+	}
+
 	// Info text
 	text := "A game for GMTK game jam 2025"
 	drawTextAt(text, 20, 10, screen)
@@ -83,7 +137,93 @@ func (t *title) updateOnBeat() {
 	t.upSubChar = (t.upSubChar + 1) % 8
 }
 
-func (t *title) update() (done bool) {
-	done = inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+// Wait for a click to start (another game mode when
+// clicking on its text), or for a solution code to be
+// typed and validated with the enter key.
+func (t *title) update(cursorX, cursorY int) (choice int, code string) {
+	t.hover = titleNone
+	for _, option := range titleOptions {
+		if cursorX >= option.x && cursorX < option.x+len(option.text)*15 &&
+			cursorY >= option.y && cursorY < option.y+36 {
+			t.hover = option.choice
+		}
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		choice = titleStart
+		if t.hover != titleNone {
+			choice = t.hover
+		}
+	}
+
+	for _, char := range ebiten.AppendInputChars(nil) {
+		if len(t.codeInput) >= titleCodeMaxLen {
+			break
+		}
+		if (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '2' && char <= '7') {
+			t.codeInput = append(t.codeInput, unicode.ToUpper(char))
+			t.codeError = ""
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(t.codeInput) > 0 {
+		t.codeInput = t.codeInput[:len(t.codeInput)-1]
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) && len(t.codeInput) > 0 {
+		code = string(t.codeInput)
+		t.codeInput = t.codeInput[:0]
+	}
+
 	return
 }
+
+// The scene of the title screen
+type titleScene struct{}
+
+func (s *titleScene) enter(g *game) {}
+
+func (s *titleScene) exit(g *game) {}
+
+// Start the game mode chosen on the title screen, or
+// watch the solution given by a sharing code
+func (s *titleScene) update(g *game) {
+	choice, code := g.title.update(g.cursor.x, g.cursor.y)
+	if code != "" {
+		solution, err := decodeSolution(code, time.Now())
+		if err != nil {
+			g.title.codeError = err.Error()
+			g.soundEngine.nextSounds[soundBlip] = true
+		} else {
+			g.startReplay(solution)
+			g.soundEngine.nextSounds[soundGo] = true
+		}
+	} else if choice == titleEndless {
+		g.startEndless()
+		g.soundEngine.nextSounds[soundGo] = true
+	} else if choice == titleTimeAttack {
+		g.startTimeAttack()
+		g.soundEngine.nextSounds[soundGo] = true
+	} else if choice == titleDaily {
+		if g.startDaily() {
+			g.soundEngine.nextSounds[soundGo] = true
+		} else {
+			g.soundEngine.nextSounds[soundBlip] = true
+		}
+	} else if choice == titleStart {
+		g.level = 0
+		g.scenes.set(g, &introScene{})
+		g.soundEngine.nextSounds[soundGo] = true
+	}
+}
+
+func (s *titleScene) onBeat(g *game) {
+	g.title.updateOnBeat()
+}
+
+func (s *titleScene) onHalfBeat(g *game) {
+	s.onBeat(g)
+}
+
+func (s *titleScene) draw(g *game, screen *ebiten.Image) {
+	g.title.draw(screen)
+}
@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 CUB 2: Origins, a game for GMTK Game Jam 2025
 Copyright (C) 2025 Loïg Jezequel
@@ -17,7 +19,10 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 package main
 
-import "math/rand/v2"
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
 
 func (g *game) Update() error {
 
@@ -25,183 +30,77 @@ func (g *game) Update() error {
 
 	g.cursor.update()
 
-	newBeat, halfBeat := g.sequencer.update(&g.soundEngine)
-
-	if newBeat {
-		g.buttonSet.setBeat()
-		g.character.setBeat()
-		g.title.updateOnBeat()
-		if g.state == stateIntro {
-			if g.intro.updateOnBeat() {
-				g.soundEngine.nextSounds[rand.IntN(3)+soundBlip2] = true
-			}
-		}
-		if g.state == stateEnd {
-			if g.end.updateOnBeat() {
-				g.soundEngine.nextSounds[rand.IntN(3)+soundBlip2] = true
-			}
-		}
-	}
-
-	if halfBeat {
-		g.buttonSet.setHalfBeat()
-		g.character.setHalfBeat()
-		g.title.updateOnBeat()
-		if g.state == stateIntro {
-			if g.intro.updateOnBeat() {
-				g.soundEngine.nextSounds[rand.IntN(3)+soundBlip2] = true
-			}
-		}
-		if g.state == stateEnd {
-			if g.end.updateOnBeat() {
-				g.soundEngine.nextSounds[rand.IntN(3)+soundBlip2] = true
-			}
-		}
-	}
-
-	if g.state == stateTitle {
-		if g.title.update() {
-			g.level = 0
-			g.state = stateIntro
-			g.soundEngine.nextSounds[soundGo] = true
-		}
-		return nil
-	}
+	g.scenes.update(g)
 
-	if g.state == stateIntro {
-		if g.intro.update() {
-			g.setLevel()
-			g.soundEngine.nextSounds[soundGo] = true
-		}
-		return nil
-	}
+	return nil
+}
 
-	if g.state == stateEnd {
-		if g.end.update() {
-			g.reset()
-			g.soundEngine.nextSounds[soundGo] = true
-		}
-		return nil
-	}
+// A key giving a move when recording on the beat
+type rhythmKey struct {
+	key  ebiten.Key
+	move int
+}
 
-	clicked, buttonKind, positionInSequence, smallPosition :=
-		g.buttonSet.update(g.cursor.x, g.cursor.y, g.state == stateSetupSequence, g.level >= levelStepReset)
+// Keys giving the moves when recording on the beat,
+// for each choice of keys in the settings
+var rhythmKeys = [numRecordKeys][]rhythmKey{
+	recordKeysArrows: {
+		{ebiten.KeyArrowUp, moveUp},
+		{ebiten.KeyArrowRight, moveRight},
+		{ebiten.KeyArrowDown, moveDown},
+		{ebiten.KeyArrowLeft, moveLeft},
+		{ebiten.KeySpace, nothing},
+	},
+	recordKeysWASD: {
+		{ebiten.KeyW, moveUp},
+		{ebiten.KeyD, moveRight},
+		{ebiten.KeyS, moveDown},
+		{ebiten.KeyA, moveLeft},
+		{ebiten.KeySpace, nothing},
+	},
+}
 
-	if clicked && buttonKind == buttonIncBPM {
-		g.bpm += 5
-		if g.bpm > globalMaxBPM {
-			g.bpm = globalMaxBPM
-		} else {
-			g.sequencer.setBpm(g.bpm)
+// Record the sequence of moves from the keys pressed on
+// the beat, and play it once the last slot is recorded.
+func (g *game) updateRecording(newBeat bool) {
+	if newBeat {
+		g.recorder.setBeat()
+		if g.recorder.countIn() > 0 {
+			g.soundEngine.nextSounds[soundBlip] = true
 		}
 	}
 
-	if clicked && buttonKind == buttonDecBPM {
-		g.bpm -= 5
-		if g.bpm < globalMinBPM {
-			g.bpm = globalMinBPM
-		} else {
-			g.sequencer.setBpm(g.bpm)
+	for _, rhythmKey := range rhythmKeys[g.settings.RecordKeys] {
+		if inpututil.IsKeyJustPressed(rhythmKey.key) {
+			g.recorder.press(rhythmKey.move, g.sequencer.framesSinceBeat(), g.sequencer.framesPerBeat)
+			copy(g.character.moveSequence, g.recorder.moves)
 		}
 	}
 
-	if clicked && buttonKind == buttonToggleSound {
-		g.soundEngine.toggleSound()
+	if g.recorder.done(g.sequencer.framesSinceBeat(), g.sequencer.framesPerBeat) {
+		g.playSequence()
 	}
-
-	if clicked && buttonKind == buttonReset {
-		g.character.restoreMoves()
-		g.character.reset(levelSet[g.level], g.state == stateSetupSequence)
-		g.state = stateSetupSequence
-		g.soundEngine.nextSounds[soundBack] = true
-		g.boxSwitcher.reset()
-	} else {
-
-		// Setup a sequence
-		if g.state == stateSetupSequence {
-			if clicked && buttonKind == buttonPlay {
-				g.soundEngine.nextSounds[soundGo] = true
-				g.state = statePlaySequence
-				g.buttonSet.setFirstLoop()
-				g.character.storeMoves()
-			} else if clicked && buttonKind == buttonSelectMove {
-				g.character.moveSequence[positionInSequence] =
-					getMoveFromChoice(smallPosition, g.character.moveSequence[positionInSequence], g.level >= levelStepReset)
-			}
-		} else if g.state == statePlaySequence {
-			// Run a sequence
-
-			g.boxSwitcher.update()
-
-			if newBeat && g.character.checkGoal() {
-				g.boxSwitcher.reset()
-				g.level++
-				g.evolutionSubStep++
-				g.soundEngine.nextSounds[soundSuccess] = true
-				g.setLevel()
-				if g.level == levelSteps[0] || g.level == levelSteps[1] || g.level == levelSteps[2] {
-					g.oldBpm = g.bpm
-					switch g.level {
-					case levelSteps[0]:
-						g.bpm = 50
-					case levelSteps[1]:
-						g.bpm = 30
-					case levelSteps[2]:
-						g.bpm = 40
-					}
-					g.sequencer.setBpm(g.bpm)
-					for pos := 0; pos < len(g.character.moveSequence); pos++ {
-						if pos < 3 {
-							g.character.moveSequence[pos] = moveRight
-						} else {
-							g.character.moveSequence[pos] = moveDown
-						}
-					}
-				} else if g.level == levelSteps[0]+1 || g.level == levelSteps[1]+1 || g.level == levelSteps[2]+1 {
-					g.bpm = g.oldBpm
-					g.sequencer.setBpm(g.bpm)
-				}
-				return nil
-			}
-
-			if newBeat {
-				playSound, soundID := g.character.updateOnBeat()
-				if playSound {
-					g.soundEngine.nextSounds[soundID] = true
-				}
-			}
-
-			if halfBeat {
-				playSound, soundID, switchBoxes := g.character.updateOnHalfBeat()
-				if playSound {
-					g.soundEngine.nextSounds[soundID] = true
-				}
-				if switchBoxes {
-					g.boxSwitcher.setUp(g.character.x, g.character.y,
-						g.character.displayX, g.character.displayY,
-						len(g.character.moveSequence), g.character.currentMovePosition,
-						g.bpm,
-						g.character.levelArea[g.character.y][g.character.x]-levelUpBox,
-						g.character.moveSequence[g.character.currentMovePosition])
-				}
-			}
-
-		}
-
-	}
-
-	return nil
 }
 
-// Retrieve the move chosen from the number of a small choice
-// button and the current move in the sequence of moves
-func getMoveFromChoice(choice, currentMove int, withReset bool) (newMove int) {
-	newMove = choice
-	if newMove >= currentMove {
-		newMove++
-	}
-	if !withReset && newMove >= moveReset {
-		newMove++
+// Start the visual effects of the events of a half beat.
+func (g *game) setUpEffects(events []halfBeatEvent) {
+	for _, event := range events {
+		switch event.kind {
+		case eventBoxSwitch:
+			var b boxSwitcher
+			floorX, floorY := g.camera.toScreen(
+				g.character.displayX+float64(event.toX*globalTileSize),
+				g.character.displayY+float64(event.toY*globalTileSize))
+			b.setUp(floorX, floorY, g.camera.scale,
+				len(g.character.moveSequence), g.character.currentMovePosition,
+				g.bpm, event.floorMove, event.seqMove)
+			g.boxSwitchers = append(g.boxSwitchers, b)
+		case eventTeleport:
+			var t teleportEffect
+			t.setUp(event.fromX, event.fromY, event.toX, event.toY,
+				g.character.displayX, g.character.displayY,
+				g.bpm, event.tile)
+			g.teleportEffects = append(g.teleportEffects, t)
+		}
 	}
-	return
 }